@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// streamAnnotation is the user-set overlay for one conversation: a
+// custom display title and a pinned flag. The conversation files stay
+// read-only; annotations live in their own store.
+type streamAnnotation struct {
+	Title  string `json:"title,omitempty"`
+	Pinned bool   `json:"pinned,omitempty"`
+}
+
+// annotationStore persists per-stream annotations in a small JSON file
+// (-annotations-file), surviving restarts.
+type annotationStore struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]streamAnnotation // stream ID -> annotation
+}
+
+// newAnnotationStore loads the annotations file, or starts empty if it
+// doesn't exist yet.
+func newAnnotationStore(path string) (*annotationStore, error) {
+	a := &annotationStore{path: path, entries: make(map[string]streamAnnotation)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &a.entries); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *annotationStore) get(streamID string) (streamAnnotation, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ann, ok := a.entries[streamID]
+	return ann, ok
+}
+
+// set records an annotation and persists the whole store atomically,
+// the same temp-and-rename dance as the bookmark store.
+func (a *annotationStore) set(streamID string, ann streamAnnotation) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if ann == (streamAnnotation{}) {
+		delete(a.entries, streamID)
+	} else {
+		a.entries[streamID] = ann
+	}
+
+	data, err := json.Marshal(a.entries)
+	if err != nil {
+		return err
+	}
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// applyAnnotation overlays a stored annotation onto listing metadata.
+func (s *ClaudeStorage) applyAnnotation(meta *streamMetadata) {
+	if s.annotations == nil {
+		return
+	}
+	ann, ok := s.annotations.get(meta.ID)
+	if !ok {
+		return
+	}
+	if ann.Title != "" {
+		meta.Title = truncate(ann.Title, maxTitleLen)
+	}
+	meta.Pinned = ann.Pinned
+}
+
+// handleAnnotate reads or updates the user annotation for a stream. An
+// empty PUT body field clears that field; clearing both removes the
+// annotation entirely.
+//
+// GET /_annotate/{streamID}
+// PUT /_annotate/{streamID} with body {"title":"...","pinned":true}
+func (s *ClaudeStorage) handleAnnotate(w http.ResponseWriter, r *http.Request) {
+	if s.annotations == nil {
+		apiError(w, http.StatusForbidden, codeForbidden, "annotation store disabled (-annotations-file not set)")
+		return
+	}
+	streamID := strings.TrimPrefix(r.URL.Path, "/_annotate/")
+
+	switch r.Method {
+	case http.MethodGet:
+		ann, ok := s.annotations.get(streamID)
+		if !ok {
+			apiError(w, http.StatusNotFound, codeStreamNotFound, "no annotation for stream")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ann)
+
+	case http.MethodPut:
+		var ann streamAnnotation
+		if err := json.NewDecoder(r.Body).Decode(&ann); err != nil {
+			apiError(w, http.StatusBadRequest, codeBadRequest, `invalid request body (want {"title":"...","pinned":true})`)
+			return
+		}
+		if _, err := s.getPathCtx(r.Context(), streamID); err != nil {
+			apiErrorFrom(w, err)
+			return
+		}
+		if err := s.annotations.set(streamID, ann); err != nil {
+			apiError(w, http.StatusInternalServerError, codeInternal, "persist annotation")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		apiError(w, http.StatusMethodNotAllowed, codeMethodNotAllow, "method not allowed")
+	}
+}
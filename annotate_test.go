@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnnotateTitleAndPinInListing(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	oldID, newID := testUUID(1), testUUID(2)
+	oldPath := writeStream(t, projectDir, oldID, userEntry(testUUID(10), "derived old title"))
+	writeStream(t, projectDir, newID, userEntry(testUUID(11), "derived new title"))
+	base := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(oldPath, base, base); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStorage(t, claudeDir)
+
+	storePath := filepath.Join(t.TempDir(), "annotations.json")
+	store, err := newAnnotationStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.annotations = store
+
+	// Pin the older conversation and give it a custom title.
+	body := `{"title":"My pinned session","pinned":true}`
+	r := httptest.NewRequest("PUT", "/_annotate/"+oldID, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.handleAnnotate(w, r)
+	if w.Code != 204 {
+		t.Fatalf("PUT status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	r = httptest.NewRequest("GET", "/_streams", nil)
+	w = httptest.NewRecorder()
+	s.handleListStreams(w, r)
+	var page struct {
+		Items []*streamMetadata `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 {
+		t.Fatalf("got %d streams", len(page.Items))
+	}
+	// The pinned conversation leads despite being older, with the
+	// override replacing the derived title.
+	if page.Items[0].ID != oldID || !page.Items[0].Pinned {
+		t.Errorf("first item = %s pinned=%v, want the pinned %s", page.Items[0].ID, page.Items[0].Pinned, oldID)
+	}
+	if page.Items[0].Title != "My pinned session" {
+		t.Errorf("title = %q, want the override", page.Items[0].Title)
+	}
+
+	// The store survives a restart.
+	reloaded, err := newAnnotationStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ann, ok := reloaded.get(oldID); !ok || !ann.Pinned || ann.Title != "My pinned session" {
+		t.Errorf("reloaded annotation = %+v/%v", ann, ok)
+	}
+
+	// Clearing both fields removes the annotation entirely.
+	r = httptest.NewRequest("PUT", "/_annotate/"+oldID, strings.NewReader(`{}`))
+	w = httptest.NewRecorder()
+	s.handleAnnotate(w, r)
+	if w.Code != 204 {
+		t.Fatalf("clear status = %d", w.Code)
+	}
+	r = httptest.NewRequest("GET", "/_annotate/"+oldID, nil)
+	w = httptest.NewRecorder()
+	s.handleAnnotate(w, r)
+	if w.Code != 404 {
+		t.Errorf("status after clear = %d, want 404", w.Code)
+	}
+}
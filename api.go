@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Extra HTTP endpoints beyond the durable-streams protocol. These live
+// under "_"-prefixed paths so they can never collide with conversation
+// UUIDs.
+
+// scanTimeout bounds how long a scanning endpoint may run before it
+// returns partial results flagged truncated. Set by -scan-timeout.
+var scanTimeout = 30 * time.Second
+
+// scanContext derives a context that expires after -scan-timeout, so
+// whole-file scans can't run away on huge conversations.
+func scanContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if scanTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, scanTimeout)
+}
+
+// handleStreamJSON serves a conversation as one JSON array, streaming
+// each message as it is scanned instead of buffering the whole array.
+// The response is flushed periodically so clients see progress and
+// server memory stays bounded on large conversations.
+//
+// GET /_stream/{streamID}?offset=N&thinking=only|exclude|include
+func (s *ClaudeStorage) handleStreamJSON(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_stream/")
+
+	path, err := s.getPath(streamID)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	// Raw JSON includes thinking blocks unless asked otherwise.
+	thinking, err := parseThinkingMode(r.URL.Query().Get("thinking"), thinkingInclude)
+	if err != nil {
+		apiError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	// Default output is compact; ?pretty=1 indents for humans.
+	pretty := r.URL.Query().Get("pretty") == "1"
+
+	var offset int64
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || offset < 0 {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid offset")
+			return
+		}
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	// openStream decompresses gzipped conversations, so the offset is
+	// always in uncompressed bytes and can't be seeked to directly.
+	if _, err := io.CopyN(io.Discard, f, offset); err != nil && err != io.EOF {
+		apiError(w, http.StatusInternalServerError, codeInternal, "seek")
+		return
+	}
+
+	// Note: intermediate proxies must not buffer this response, or the
+	// incremental flushes below are defeated.
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher, _ := w.(http.Flusher)
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	io.WriteString(w, "[")
+	// Flush headers and the opening bracket right away so long-polling
+	// clients see the response begin before the first full batch.
+	if flusher != nil {
+		flusher.Flush()
+	}
+	n := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !json.Valid(line) {
+			continue
+		}
+		if n > 0 {
+			io.WriteString(w, ",")
+		}
+		out := filterThinking(line, thinking)
+		if pretty {
+			io.WriteString(w, "\n")
+			out = prettyJSON(out, "  ")
+			io.WriteString(w, "  ")
+		}
+		w.Write(out)
+		n++
+		if n%flushEvery == 0 && flusher != nil {
+			flusher.Flush()
+		}
+	}
+	if pretty && n > 0 {
+		io.WriteString(w, "\n")
+	}
+	io.WriteString(w, "]")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// flushEvery is how many streamed messages to write between flushes.
+const flushEvery = 16
+
+// handleRange returns the messages between two message UUIDs, inclusive,
+// along with their byte offsets. Bookmarking by message identity is more
+// robust than byte offsets when files are rewritten.
+//
+// GET /_range/{streamID}?from_uuid=X&to_uuid=Y
+func (s *ClaudeStorage) handleRange(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_range/")
+	fromUUID := r.URL.Query().Get("from_uuid")
+	toUUID := r.URL.Query().Get("to_uuid")
+	if fromUUID == "" || toUUID == "" {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "from_uuid and to_uuid are required")
+		return
+	}
+
+	path, err := s.getPath(streamID)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	ctx, cancel := scanContext(r.Context())
+	defer cancel()
+
+	type rangeMessage struct {
+		Offset string          `json:"offset"`
+		Data   json.RawMessage `json:"data"`
+	}
+	var (
+		messages  []rangeMessage
+		inRange   bool
+		sawFrom   bool
+		sawTo     bool
+		offset    int64
+		endUUID   string
+		truncated bool
+	)
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+	scanner.Split(scanLinesKeepCR)
+
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		if lines%ctxCheckEvery == 0 && ctx.Err() != nil {
+			truncated = true
+			break
+		}
+
+		line := scanner.Bytes()
+		lineLen := int64(len(line) + 1)
+		data := bytes.TrimSuffix(line, []byte{'\r'})
+
+		var entry struct {
+			UUID string `json:"uuid"`
+		}
+		if err := json.Unmarshal(data, &entry); err == nil && entry.UUID != "" {
+			if entry.UUID == fromUUID {
+				sawFrom = true
+			}
+			if entry.UUID == toUUID {
+				sawTo = true
+			}
+			// Whichever endpoint appears first opens the range; the
+			// other closes it, so reversed arguments still work.
+			if !inRange && (entry.UUID == fromUUID || entry.UUID == toUUID) {
+				inRange = true
+				if entry.UUID == fromUUID {
+					endUUID = toUUID
+				} else {
+					endUUID = fromUUID
+				}
+			}
+			if inRange {
+				messages = append(messages, rangeMessage{
+					Offset: string(offsetFromInt(offset + lineLen)),
+					Data:   append([]byte(nil), data...),
+				})
+				if entry.UUID == endUUID {
+					offset += lineLen
+					break
+				}
+			}
+		}
+		offset += lineLen
+	}
+	if err := scanner.Err(); err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "scan stream")
+		return
+	}
+
+	if !truncated && (!sawFrom || !sawTo) {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "uuid not found in stream")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Messages  []rangeMessage `json:"messages"`
+		Truncated bool           `json:"truncated,omitempty"`
+	}{messages, truncated})
+}
+
+// flushCaches clears cached derived state. With a stream ID it only
+// drops that stream's entries; otherwise everything goes. The file index
+// is dropped too (except the special streams) so paths re-resolve on the
+// next request — useful when the filesystem changed in ways the watcher
+// missed.
+func (s *ClaudeStorage) flushCaches(streamID string) {
+	if streamID != "" {
+		path, err := s.getPath(streamID)
+		if err == nil {
+			s.metaCache.mu.Lock()
+			delete(s.metaCache.entries, path)
+			s.metaCache.mu.Unlock()
+			s.hashes.mu.Lock()
+			delete(s.hashes.entries, path)
+			s.hashes.mu.Unlock()
+		}
+		s.mu.Lock()
+		if streamID != "_history" {
+			delete(s.fileIndex, streamID)
+		}
+		s.mu.Unlock()
+		return
+	}
+
+	s.metaCache.mu.Lock()
+	s.metaCache.entries = make(map[string]metadataCacheEntry)
+	s.metaCache.mu.Unlock()
+
+	s.hashes.mu.Lock()
+	s.hashes.entries = make(map[string]hashCacheEntry)
+	s.hashes.mu.Unlock()
+
+	s.mu.Lock()
+	for id := range s.fileIndex {
+		if id != "_history" {
+			delete(s.fileIndex, id)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// handleCacheFlush clears caches for debugging stale data.
+//
+// POST /_cache/flush[?stream=ID]   (admin only)
+func (s *ClaudeStorage) handleCacheFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apiError(w, http.StatusMethodNotAllowed, codeMethodNotAllow, "method not allowed")
+		return
+	}
+	s.flushCaches(r.URL.Query().Get("stream"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleHealthz is the readiness probe: 200 once the initial index has
+// completed, 503 while warming up. Reads work during warm-up via the
+// resolution fallback, but listings are incomplete until ready.
+//
+// GET /_healthz
+func (s *ClaudeStorage) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !s.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "warming"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+}
+
+// handleInfo reports server state for diagnostics: how many streams are
+// indexed, how many directories are watched, and whether any subtrees
+// have degraded to polling.
+//
+// GET /_info
+func (s *ClaudeStorage) handleInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	info := struct {
+		Streams       int `json:"streams"`
+		WatchCount    int `json:"watchCount"`
+		PolledDirs    int `json:"polledDirs"`
+		DroppedEvents int `json:"droppedEvents"`
+	}{
+		Streams:       len(s.fileIndex),
+		WatchCount:    s.watchCount,
+		PolledDirs:    len(s.polledDirs),
+		DroppedEvents: s.droppedEvents,
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
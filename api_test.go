@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamJSONValid(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id,
+		userEntry(testUUID(2), "first"),
+		"not json at all",
+		assistantEntry(testUUID(3), "test-model", "second"),
+	)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_stream/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleStreamJSON(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var messages []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &messages); err != nil {
+		t.Fatalf("body is not a valid JSON array: %v\n%s", err, w.Body.String())
+	}
+	// The invalid line is skipped, not passed through to break the array.
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	if messages[0]["type"] != "user" || messages[1]["type"] != "assistant" {
+		t.Errorf("unexpected message order: %v", messages)
+	}
+}
+
+func TestRangeByUUID(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := []string{
+		userEntry(testUUID(2), "one"),
+		assistantEntry(testUUID(3), "test-model", "two"),
+		userEntry(testUUID(4), "three"),
+		assistantEntry(testUUID(5), "test-model", "four"),
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	get := func(from, to string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/_range/"+id+"?from_uuid="+from+"&to_uuid="+to, nil)
+		w := httptest.NewRecorder()
+		s.handleRange(w, r)
+		return w
+	}
+
+	w := get(testUUID(3), testUUID(4))
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Messages []struct {
+			Offset string          `json:"offset"`
+			Data   json.RawMessage `json:"data"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Messages) != 2 {
+		t.Fatalf("got %d messages, want the 2 inside the range", len(resp.Messages))
+	}
+	if string(resp.Messages[0].Data) != lines[1] {
+		t.Errorf("first message = %s, want %s", resp.Messages[0].Data, lines[1])
+	}
+
+	// Reversed arguments cover the same slice.
+	if w := get(testUUID(4), testUUID(3)); w.Code != 200 {
+		t.Errorf("reversed range: status = %d", w.Code)
+	}
+
+	// An unknown UUID is a client error.
+	if w := get(testUUID(3), testUUID(99)); w.Code != 400 {
+		t.Errorf("unknown to_uuid: status = %d, want 400", w.Code)
+	}
+}
+
+func TestStreamJSONPretty(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "hello"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_stream/"+id+"?pretty=1", nil)
+	w := httptest.NewRecorder()
+	s.handleStreamJSON(w, r)
+
+	var messages []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &messages); err != nil {
+		t.Fatalf("pretty output is not valid JSON: %v\n%s", err, w.Body.String())
+	}
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+}
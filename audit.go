@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audit logging for compliance: every conversation read is appended to
+// a dedicated file, independent of general access logs. Enabled by
+// -audit-log.
+
+// auditMaxBytes caps the audit file; when exceeded it is rotated once
+// to <path>.1 and started fresh, so the pair bounds disk use.
+const auditMaxBytes = 64 << 20
+
+// auditEntry is one JSON line in the audit file.
+type auditEntry struct {
+	Time      string `json:"time"`
+	RequestID string `json:"requestId,omitempty"`
+	Identity  string `json:"identity"`
+	Stream    string `json:"stream"`
+	Offset    string `json:"offset"`
+	Bytes     int    `json:"bytes"`
+}
+
+// auditLogger serializes appends to the audit file and handles
+// rotation.
+type auditLogger struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+}
+
+func newAuditLogger(path string) (*auditLogger, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &auditLogger{path: path, f: f, size: info.Size()}, nil
+}
+
+// record appends one entry, rotating first if the file is full. Audit
+// failures are logged but never fail the read itself.
+func (a *auditLogger) record(e auditEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.size+int64(len(data)) > auditMaxBytes {
+		a.f.Close()
+		if err := os.Rename(a.path, a.path+".1"); err != nil {
+			log.Printf("audit rotate: %v", err)
+		}
+		f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			log.Printf("audit reopen: %v", err)
+			a.f = nil
+			return
+		}
+		a.f = f
+		a.size = 0
+	}
+	if a.f == nil {
+		return
+	}
+	if _, err := a.f.Write(data); err != nil {
+		log.Printf("audit write: %v", err)
+		return
+	}
+	a.size += int64(len(data))
+}
+
+type requestIDKey struct{}
+
+// withRequestID attaches the request's correlation ID so storage-layer
+// log lines can carry the same ID the client saw in X-Request-ID.
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID generates a short random ID for requests that did not
+// bring their own X-Request-ID.
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+type identityKey struct{}
+
+// withIdentity attaches the caller's identity, derived from the auth
+// token, for audit entries.
+func withIdentity(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+func identityFrom(ctx context.Context) string {
+	id, _ := ctx.Value(identityKey{}).(string)
+	if id == "" {
+		return "anonymous"
+	}
+	return id
+}
+
+// requestIdentity derives a stable identity from the request's bearer
+// token: a short hash, so the token itself never lands in the audit
+// file. Requests without a token are anonymous.
+func requestIdentity(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:4])
+}
+
+// auditRead records a conversation read when auditing is enabled.
+func (s *ClaudeStorage) auditRead(ctx context.Context, streamID string, offset string, bytes int) {
+	if s.audit == nil {
+		return
+	}
+	s.audit.record(auditEntry{
+		Time:      time.Now().UTC().Format(time.RFC3339),
+		RequestID: requestIDFrom(ctx),
+		Identity:  identityFrom(ctx),
+		Stream:    streamID,
+		Offset:    offset,
+		Bytes:     bytes,
+	})
+}
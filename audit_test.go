@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestAuditReadRecorded(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "audited"))
+	s := newTestStorage(t, claudeDir)
+
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := newAuditLogger(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.audit = audit
+
+	ctx := withIdentity(context.Background(), "token:deadbeef")
+	if _, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry auditEntry
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &entry); err != nil {
+		t.Fatalf("bad audit line %s: %v", data, err)
+	}
+	if entry.Stream != id || entry.Identity != "token:deadbeef" {
+		t.Errorf("audit entry = %+v", entry)
+	}
+	if entry.Bytes <= 0 || entry.Time == "" {
+		t.Errorf("audit entry missing bytes/time: %+v", entry)
+	}
+}
+
+func TestRequestIdentityHashesToken(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	if got := requestIdentity(r); got != "anonymous" {
+		t.Errorf("no token identity = %s, want anonymous", got)
+	}
+
+	r.Header.Set("Authorization", "Bearer super-secret")
+	got := requestIdentity(r)
+	if !strings.HasPrefix(got, "token:") || strings.Contains(got, "super-secret") {
+		t.Errorf("identity = %s, want a hash that never exposes the token", got)
+	}
+	// The hash is stable so the same caller is correlatable across entries.
+	if again := requestIdentity(r); again != got {
+		t.Errorf("identity not stable: %s vs %s", got, again)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// bookmarkStore persists a last-read offset per stream in a small JSON
+// file (-bookmarks-file), so lightweight clients can resume where they
+// left off without keeping local state.
+type bookmarkStore struct {
+	mu      sync.Mutex
+	path    string
+	offsets map[string]string // stream ID -> offset
+}
+
+// newBookmarkStore loads the bookmark file, or starts empty if it
+// doesn't exist yet.
+func newBookmarkStore(path string) (*bookmarkStore, error) {
+	b := &bookmarkStore{path: path, offsets: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &b.offsets); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *bookmarkStore) get(streamID string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	offset, ok := b.offsets[streamID]
+	return offset, ok
+}
+
+// set records a bookmark and persists the whole store atomically: the
+// file is rewritten to a temp path and renamed over the old one, so a
+// crash mid-write never leaves a corrupt store.
+func (b *bookmarkStore) set(streamID, offset string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.offsets[streamID] = offset
+
+	data, err := json.Marshal(b.offsets)
+	if err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// handleBookmark reads or updates the stored last-read offset for a
+// stream.
+//
+// GET /_bookmark/{streamID}
+// PUT /_bookmark/{streamID} with body {"offset":"..."}
+func (s *ClaudeStorage) handleBookmark(w http.ResponseWriter, r *http.Request) {
+	if s.bookmarks == nil {
+		apiError(w, http.StatusForbidden, codeForbidden, "bookmark store disabled (-bookmarks-file not set)")
+		return
+	}
+	streamID := strings.TrimPrefix(r.URL.Path, "/_bookmark/")
+
+	switch r.Method {
+	case http.MethodGet:
+		offset, ok := s.bookmarks.get(streamID)
+		if !ok {
+			apiError(w, http.StatusNotFound, codeBookmarkNotFound, "no bookmark for stream")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			StreamID string `json:"streamId"`
+			Offset   string `json:"offset"`
+		}{streamID, offset})
+
+	case http.MethodPut:
+		var body struct {
+			Offset string `json:"offset"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Offset == "" {
+			apiError(w, http.StatusBadRequest, codeBadRequest, `invalid request body (want {"offset":"..."})`)
+			return
+		}
+		if _, err := s.getPathCtx(r.Context(), streamID); err != nil {
+			apiErrorFrom(w, err)
+			return
+		}
+		if err := s.bookmarks.set(streamID, body.Offset); err != nil {
+			apiError(w, http.StatusInternalServerError, codeInternal, "persist bookmark")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		apiError(w, http.StatusMethodNotAllowed, codeMethodNotAllow, "method not allowed")
+	}
+}
+
+// ensure the parent directory for a bookmark file exists; a missing dir
+// would otherwise fail only on the first PUT.
+func ensureParentDir(path string) error {
+	return os.MkdirAll(filepath.Dir(path), 0700)
+}
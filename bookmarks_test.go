@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBookmarkSetGetAndRestart(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "hi"))
+	s := newTestStorage(t, claudeDir)
+
+	storePath := filepath.Join(t.TempDir(), "bookmarks.json")
+	store, err := newBookmarkStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.bookmarks = store
+
+	// No bookmark yet.
+	r := httptest.NewRequest("GET", "/_bookmark/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleBookmark(w, r)
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404 before any PUT", w.Code)
+	}
+
+	offset := string(offsetFromInt(42))
+	r = httptest.NewRequest("PUT", "/_bookmark/"+id, strings.NewReader(`{"offset":"`+offset+`"}`))
+	w = httptest.NewRecorder()
+	s.handleBookmark(w, r)
+	if w.Code != 204 {
+		t.Fatalf("PUT status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	r = httptest.NewRequest("GET", "/_bookmark/"+id, nil)
+	w = httptest.NewRecorder()
+	s.handleBookmark(w, r)
+	var resp struct {
+		Offset string `json:"offset"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Offset != offset {
+		t.Errorf("offset = %s, want %s", resp.Offset, offset)
+	}
+
+	// A fresh store over the same file sees the persisted bookmark,
+	// as after a server restart.
+	reloaded, err := newBookmarkStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reloaded.get(id); !ok || got != offset {
+		t.Errorf("reloaded bookmark = %q/%v, want %s", got, ok, offset)
+	}
+}
+
+func TestBookmarkDisabled(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "hi"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_bookmark/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleBookmark(w, r)
+	if w.Code != 403 {
+		t.Errorf("status = %d, want 403 without -bookmarks-file", w.Code)
+	}
+}
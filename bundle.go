@@ -0,0 +1,91 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxBundleStreams caps how many conversations one bundle may contain.
+const maxBundleStreams = 50
+
+// handleBundle streams a zip archive of the requested conversations:
+// raw JSONL by default, or rendered text/markdown via ?format=. The zip
+// is written straight to the response, so memory stays bounded no
+// matter how large the conversations are. IDs that can't be resolved
+// are listed in an errors.txt entry instead of failing the download.
+//
+// GET /_bundle?ids=a,b,c[&format=markdown]
+func (s *ClaudeStorage) handleBundle(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	if idsParam == "" {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "ids is required")
+		return
+	}
+	ids := strings.Split(idsParam, ",")
+	if len(ids) > maxBundleStreams {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "too many stream IDs")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	ext := ".jsonl"
+	switch format {
+	case "", "jsonl":
+		format = ""
+	case "text":
+		ext = ".txt"
+	case "markdown":
+		ext = ".md"
+	default:
+		apiError(w, http.StatusBadRequest, codeBadRequest, "invalid format (want jsonl, text or markdown)")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="conversations.zip"`)
+
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	var errLines []string
+	for _, id := range ids {
+		path, err := s.getPathCtx(r.Context(), id)
+		if err != nil {
+			errLines = append(errLines, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		f, err := openStream(path)
+		if err != nil {
+			errLines = append(errLines, fmt.Sprintf("%s: open failed", id))
+			continue
+		}
+
+		entry, err := zw.Create(id + ext)
+		if err != nil {
+			f.Close()
+			return // response already committed; nothing sensible to add
+		}
+		if format == "" {
+			_, err = io.Copy(entry, f)
+		} else {
+			err = renderConversation(entry, f, renderOptions{
+				format:   format,
+				thinking: thinkingExclude,
+				redact:   r.URL.Query().Get("redact") == "1",
+			})
+		}
+		f.Close()
+		if err != nil {
+			errLines = append(errLines, fmt.Sprintf("%s: %v", id, err))
+		}
+	}
+
+	if len(errLines) > 0 {
+		if entry, err := zw.Create("errors.txt"); err == nil {
+			io.WriteString(entry, strings.Join(errLines, "\n")+"\n")
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readZip maps entry name -> content for a zip response body.
+func readZip(t *testing.T, body []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("bad zip: %v", err)
+	}
+	entries := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(data)
+	}
+	return entries
+}
+
+func TestBundleZip(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	aID, bID := testUUID(1), testUUID(2)
+	aLine := userEntry(testUUID(10), "bundle me")
+	writeStream(t, projectDir, aID, aLine)
+	writeStream(t, projectDir, bID, userEntry(testUUID(11), "me too"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_bundle?ids="+aID+","+bID, nil)
+	w := httptest.NewRecorder()
+	s.handleBundle(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("content type = %s", ct)
+	}
+	entries := readZip(t, w.Body.Bytes())
+	if len(entries) != 2 {
+		t.Fatalf("zip entries = %v, want 2", entries)
+	}
+	if got := entries[aID+".jsonl"]; got != aLine+"\n" {
+		t.Errorf("%s.jsonl = %q, want the raw line", aID, got)
+	}
+}
+
+func TestBundleMissingIDReported(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	aID := testUUID(1)
+	writeStream(t, projectDir, aID, userEntry(testUUID(10), "present"))
+	s := newTestStorage(t, claudeDir)
+
+	missing := testUUID(9)
+	r := httptest.NewRequest("GET", "/_bundle?ids="+aID+","+missing+"&format=markdown", nil)
+	w := httptest.NewRecorder()
+	s.handleBundle(w, r)
+
+	entries := readZip(t, w.Body.Bytes())
+	if _, ok := entries[aID+".md"]; !ok {
+		t.Errorf("markdown entry missing: %v", entries)
+	}
+	// The unresolvable ID lands in errors.txt instead of failing the
+	// whole download.
+	if !strings.Contains(entries["errors.txt"], missing) {
+		t.Errorf("errors.txt = %q, want it to name %s", entries["errors.txt"], missing)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCacheFlush(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	idA, idB := testUUID(1), testUUID(2)
+	pathA := writeStream(t, projectDir, idA, userEntry(testUUID(3), "keep me warm"))
+	pathB := writeStream(t, projectDir, idB, userEntry(testUUID(4), "flush me"))
+	s := newTestStorage(t, claudeDir)
+
+	for _, id := range []string{idA, idB} {
+		if _, err := s.metadata(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Scoped flush drops only the named stream's entries.
+	r := httptest.NewRequest("POST", "/_cache/flush?stream="+idB, nil)
+	w := httptest.NewRecorder()
+	s.handleCacheFlush(w, r)
+	if w.Code != 204 {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	s.metaCache.mu.Lock()
+	_, hasA := s.metaCache.entries[pathA]
+	_, hasB := s.metaCache.entries[pathB]
+	s.metaCache.mu.Unlock()
+	if !hasA || hasB {
+		t.Errorf("scoped flush: cached(A)=%v cached(B)=%v, want true/false", hasA, hasB)
+	}
+
+	// Full flush clears everything, file index included.
+	r = httptest.NewRequest("POST", "/_cache/flush", nil)
+	w = httptest.NewRecorder()
+	s.handleCacheFlush(w, r)
+	if w.Code != 204 {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	s.metaCache.mu.Lock()
+	n := len(s.metaCache.entries)
+	s.metaCache.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%d metadata entries survived a full flush", n)
+	}
+	s.mu.RLock()
+	_, indexed := s.fileIndex[idA]
+	s.mu.RUnlock()
+	if indexed {
+		t.Error("file index entry survived a full flush")
+	}
+
+	// The endpoint is POST-only and sits behind the admin gate.
+	r = httptest.NewRequest("GET", "/_cache/flush", nil)
+	w = httptest.NewRecorder()
+	s.handleCacheFlush(w, r)
+	if w.Code != 405 {
+		t.Errorf("GET status = %d, want 405", w.Code)
+	}
+	r = httptest.NewRequest("POST", "/_cache/flush", nil)
+	w = httptest.NewRecorder()
+	requireAdmin("secret", s.handleCacheFlush)(w, r)
+	if w.Code != 401 {
+		t.Errorf("unauthenticated status = %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachePolicyHeaders(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "cache me right"))
+	s := newTestStorage(t, claudeDir)
+
+	// Representative endpoint per policy class: live tails must never be
+	// cached, listings go stale within seconds, exports revalidate via
+	// ETag and may be held longer.
+	for _, tc := range []struct {
+		path   string
+		policy string
+		h      http.HandlerFunc
+	}{
+		{"/_tail/" + id, cacheLive, s.handleTail},
+		{"/_streams", cacheBrief, s.handleListStreams},
+		{"/_export/" + id + ".md", cacheExport, s.handleExport},
+	} {
+		r := httptest.NewRequest("GET", tc.path, nil)
+		w := httptest.NewRecorder()
+		cachePolicy(tc.policy, tc.h)(w, r)
+		if got := w.Header().Get("Cache-Control"); got != tc.policy {
+			t.Errorf("%s: Cache-Control = %q, want %q", tc.path, got, tc.policy)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestReadHonorsContextCancellation(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	// Enough lines that the scan must pass several context checkpoints.
+	lines := make([]string, 10*ctxCheckEvery)
+	for i := range lines {
+		lines[i] = userEntry(testUUID(100+i), "filler line for a long scan")
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	// A canceled read must not dress partial results up as a full page.
+	if result != nil {
+		t.Errorf("got partial result %+v despite cancellation", result)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("canceled read took %v to return", elapsed)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Resume-chain support. When a Claude session is resumed, entries in the
+// new conversation record the prior session's UUID. Following those links
+// in both directions reconstructs the full logical session.
+
+// chainScanLines bounds how far into a file we look for a resume link;
+// it appears within the first few entries of a resumed conversation.
+const chainScanLines = 50
+
+// sessionParent returns the session UUID this conversation was resumed
+// from, or "" if it wasn't resumed.
+func sessionParent(path string) string {
+	f, err := openStream(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	for i := 0; scanner.Scan() && i < chainScanLines; i++ {
+		var entry struct {
+			ParentSessionID string `json:"parentSessionId"`
+			ResumeSessionID string `json:"resumeSessionId"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.ParentSessionID != "" {
+			return entry.ParentSessionID
+		}
+		if entry.ResumeSessionID != "" {
+			return entry.ResumeSessionID
+		}
+	}
+	return ""
+}
+
+// handleChain follows resume links backward and forward from a
+// conversation and returns the ordered chain of conversation IDs that
+// form one logical session. Broken links end the walk; visited tracking
+// guards against cycles.
+//
+// GET /_chain/{streamID}
+func (s *ClaudeStorage) handleChain(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_chain/")
+
+	if _, err := s.getPath(streamID); err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	visited := map[string]bool{streamID: true}
+
+	// Walk backward through parents.
+	var chain []string
+	for id := streamID; ; {
+		path, err := s.getPath(id)
+		if err != nil {
+			break // broken link
+		}
+		parent := sessionParent(path)
+		if parent == "" || visited[parent] {
+			break
+		}
+		visited[parent] = true
+		chain = append([]string{parent}, chain...)
+		id = parent
+	}
+	chain = append(chain, streamID)
+
+	// Walk forward: a child is any indexed conversation whose parent link
+	// points at the current tail of the chain.
+	children := s.childIndex()
+	for {
+		tail := chain[len(chain)-1]
+		child, ok := children[tail]
+		if !ok || visited[child] {
+			break
+		}
+		visited[child] = true
+		chain = append(chain, child)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Chain []string `json:"chain"`
+	}{chain})
+}
+
+// childIndex maps parent session ID -> resumed conversation ID across
+// all indexed conversations.
+func (s *ClaudeStorage) childIndex() map[string]string {
+	s.mu.RLock()
+	paths := make(map[string]string, len(s.fileIndex))
+	for id, path := range s.fileIndex {
+		if id == "_history" {
+			continue
+		}
+		paths[id] = path
+	}
+	s.mu.RUnlock()
+
+	children := make(map[string]string)
+	for id, path := range paths {
+		if parent := sessionParent(path); parent != "" {
+			children[parent] = id
+		}
+	}
+	return children
+}
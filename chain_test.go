@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+// continuationLine opens a stream that resumes the given parent session.
+func continuationLine(parentID, uuid, text string) string {
+	return fmt.Sprintf(`{"parentSessionId":%q,"type":"user","uuid":%q,"message":{"role":"user","content":%q}}`,
+		parentID, uuid, text)
+}
+
+func TestChainWalksBothDirections(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	aID, bID, cID := testUUID(1), testUUID(2), testUUID(3)
+	writeStream(t, projectDir, aID, userEntry(testUUID(10), "origin"))
+	writeStream(t, projectDir, bID, continuationLine(aID, testUUID(11), "resumed once"))
+	writeStream(t, projectDir, cID, continuationLine(bID, testUUID(12), "resumed twice"))
+	s := newTestStorage(t, claudeDir)
+
+	// Asking from the middle reconstructs the whole session, parents
+	// first.
+	r := httptest.NewRequest("GET", "/_chain/"+bID, nil)
+	w := httptest.NewRecorder()
+	s.handleChain(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Chain []string `json:"chain"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{aID, bID, cID}
+	if fmt.Sprint(resp.Chain) != fmt.Sprint(want) {
+		t.Errorf("chain = %v, want %v", resp.Chain, want)
+	}
+}
+
+func TestChainStandalone(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(10), "never resumed"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_chain/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleChain(w, r)
+	var resp struct {
+		Chain []string `json:"chain"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Chain) != 1 || resp.Chain[0] != id {
+		t.Errorf("chain = %v, want just the stream itself", resp.Chain)
+	}
+}
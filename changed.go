@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// changedStream is one modified-since entry: the ID plus the new tail so
+// a syncing client can fetch just the delta.
+type changedStream struct {
+	ID           string    `json:"id"`
+	TailOffset   string    `json:"tailOffset"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// handleChanged lists streams whose files were modified after the given
+// time, newest first, so a client can poll for deltas instead of
+// re-listing everything. The response uses the shared list envelope; the
+// since parameter itself is the cursor, so next_cursor stays empty.
+//
+// GET /_changed?since=RFC3339
+func (s *ClaudeStorage) handleChanged(w http.ResponseWriter, r *http.Request) {
+	sinceParam := r.URL.Query().Get("since")
+	if sinceParam == "" {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "missing since parameter (want RFC3339)")
+		return
+	}
+	since, err := time.Parse(time.RFC3339, sinceParam)
+	if err != nil {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "invalid since timestamp (want RFC3339)")
+		return
+	}
+
+	s.mu.RLock()
+	index := make(map[string]string, len(s.fileIndex))
+	for id, path := range s.fileIndex {
+		index[id] = path
+	}
+	s.mu.RUnlock()
+
+	changed := make([]changedStream, 0)
+	for id, path := range index {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().After(since) {
+			continue
+		}
+		changed = append(changed, changedStream{
+			ID:           id,
+			TailOffset:   string(offsetFromInt(info.Size())),
+			LastModified: info.ModTime(),
+		})
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].LastModified.After(changed[j].LastModified)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pageEnvelope{
+		Items: changed,
+		Total: len(changed),
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheckSummary(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	writeStream(t, projectDir, testUUID(1), userEntry(testUUID(3), "first"))
+	writeStream(t, projectDir, testUUID(2), userEntry(testUUID(4), "second"))
+	s := newTestStorage(t, claudeDir)
+
+	// The summary prints to stdout so scripts can grep it; capture it.
+	old := os.Stdout
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = pw
+	printCheckSummary(s, claudeDir)
+	pw.Close()
+	os.Stdout = old
+	out, err := io.ReadAll(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"Directory: " + claudeDir,
+		"Conversations: 2",
+		"Projects: 1",
+		"History: absent",
+		"OK",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
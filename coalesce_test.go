@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestCoalesceSubscriptionBatchesBurst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan durablestream.Offset, 8)
+	out := coalesceSubscription(ctx, in, 50*time.Millisecond)
+
+	// A burst of writes inside the window surfaces as one wakeup
+	// carrying the latest offset.
+	for i := 1; i <= 5; i++ {
+		in <- offsetFromInt(int64(i * 100))
+	}
+
+	select {
+	case off := <-out:
+		if got := offsetToInt(off); got != 500 {
+			t.Errorf("batched offset = %d, want the latest 500", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no coalesced notification")
+	}
+
+	// Nothing else is pending: the intermediate offsets were dropped.
+	select {
+	case off := <-out:
+		t.Errorf("unexpected second wakeup at %d", offsetToInt(off))
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// A later write after the window is a fresh notification.
+	in <- offsetFromInt(600)
+	select {
+	case off := <-out:
+		if got := offsetToInt(off); got != 600 {
+			t.Errorf("second batch offset = %d, want 600", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification after the window elapsed")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+)
+
+// compareSide summarizes one side of a conversation comparison.
+type compareSide struct {
+	ID       string `json:"id"`
+	Messages int    `json:"messages"`
+	// Unique is how many messages follow the common prefix on this side.
+	Unique int `json:"unique"`
+}
+
+// handleCompare returns a structural diff of two conversations' message
+// sequences: the length of the common prefix (aligned by message UUID),
+// where they diverge, and how many messages each side has past that
+// point. Useful for understanding where forked or resumed sessions
+// split.
+//
+// GET /_compare?a=<id>&b=<id>
+func (s *ClaudeStorage) handleCompare(w http.ResponseWriter, r *http.Request) {
+	aID := r.URL.Query().Get("a")
+	bID := r.URL.Query().Get("b")
+	if aID == "" || bID == "" {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "a and b are required")
+		return
+	}
+
+	aUUIDs, err := s.messageUUIDs(aID)
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+	bUUIDs, err := s.messageUUIDs(bID)
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+
+	prefix := 0
+	for prefix < len(aUUIDs) && prefix < len(bUUIDs) && aUUIDs[prefix] == bUUIDs[prefix] {
+		prefix++
+	}
+
+	var divergedAt string
+	if prefix > 0 && (prefix < len(aUUIDs) || prefix < len(bUUIDs)) {
+		divergedAt = aUUIDs[prefix-1]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		A            compareSide `json:"a"`
+		B            compareSide `json:"b"`
+		CommonPrefix int         `json:"commonPrefix"`
+		// DivergedAt is the UUID of the last shared message, empty when
+		// the conversations share nothing or one is a prefix of the other
+		// from the start.
+		DivergedAt string `json:"divergedAt,omitempty"`
+	}{
+		A:            compareSide{ID: aID, Messages: len(aUUIDs), Unique: len(aUUIDs) - prefix},
+		B:            compareSide{ID: bID, Messages: len(bUUIDs), Unique: len(bUUIDs) - prefix},
+		CommonPrefix: prefix,
+		DivergedAt:   divergedAt,
+	})
+}
+
+// messageUUIDs returns the ordered message UUIDs of a conversation.
+// Lines without a uuid are skipped.
+func (s *ClaudeStorage) messageUUIDs(streamID string) ([]string, error) {
+	path, err := s.getPath(streamID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var uuids []string
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	for scanner.Scan() {
+		var entry struct {
+			UUID string `json:"uuid"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.UUID == "" {
+			continue
+		}
+		uuids = append(uuids, entry.UUID)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return uuids, nil
+}
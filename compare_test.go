@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompareDivergedConversations(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	shared1 := userEntry(testUUID(10), "shared one")
+	shared2 := assistantEntry(testUUID(11), "test-model", "shared two")
+	aID, bID := testUUID(1), testUUID(2)
+	writeStream(t, projectDir, aID, shared1, shared2, userEntry(testUUID(12), "a only"))
+	writeStream(t, projectDir, bID, shared1, shared2,
+		userEntry(testUUID(13), "b only"), assistantEntry(testUUID(14), "test-model", "b more"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_compare?a="+aID+"&b="+bID, nil)
+	w := httptest.NewRecorder()
+	s.handleCompare(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		A            compareSide `json:"a"`
+		B            compareSide `json:"b"`
+		CommonPrefix int         `json:"commonPrefix"`
+		DivergedAt   string      `json:"divergedAt"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.CommonPrefix != 2 {
+		t.Errorf("commonPrefix = %d, want 2", resp.CommonPrefix)
+	}
+	if resp.A.Unique != 1 || resp.B.Unique != 2 {
+		t.Errorf("unique counts = %d/%d, want 1/2", resp.A.Unique, resp.B.Unique)
+	}
+	if resp.DivergedAt != testUUID(11) {
+		t.Errorf("divergedAt = %s, want the last shared UUID", resp.DivergedAt)
+	}
+}
+
+func TestCompareUnknownStream(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	aID := testUUID(1)
+	writeStream(t, projectDir, aID, userEntry(testUUID(10), "hi"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_compare?a="+aID+"&b="+testUUID(9), nil)
+	w := httptest.NewRecorder()
+	s.handleCompare(w, r)
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// concatLine is one NDJSON line of a /_concat response. Stream and
+// Offset together form the compound cursor: passing them back as
+// ?cursor=stream:offset resumes the concatenation exactly where it
+// stopped. Warning is set instead of Data for skipped streams.
+type concatLine struct {
+	Stream  string          `json:"stream"`
+	Offset  string          `json:"offset,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Warning string          `json:"warning,omitempty"`
+}
+
+// handleConcat streams several conversations back to back as one NDJSON
+// feed, each line annotated with its source stream ID. Missing IDs fail
+// the whole request unless ?skip_missing=1 turns them into warning
+// lines.
+//
+// GET /_concat?ids=a,b,c[&skip_missing=1][&cursor=stream:offset]
+func (s *ClaudeStorage) handleConcat(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	if idsParam == "" {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "ids is required")
+		return
+	}
+	ids := strings.Split(idsParam, ",")
+	skipMissing := r.URL.Query().Get("skip_missing") == "1"
+
+	// Parse the compound cursor before touching any stream, so a bad
+	// cursor is a clean 400 rather than a mid-stream abort.
+	var (
+		cursorStream string
+		cursorOffset int64
+	)
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		stream, offset, ok := strings.Cut(cursor, ":")
+		if !ok {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid cursor (want stream:offset)")
+			return
+		}
+		cursorStream = stream
+		cursorOffset = offsetToInt(durablestream.Offset(offset))
+	}
+
+	// Validate up front so the client gets a proper error status instead
+	// of a truncated body.
+	paths := make(map[string]string, len(ids))
+	for _, id := range ids {
+		path, err := s.getPath(id)
+		if err != nil {
+			if skipMissing {
+				continue
+			}
+			apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found: "+id)
+			return
+		}
+		paths[id] = path
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	resumed := cursorStream == ""
+	n := 0
+	for _, id := range ids {
+		if !resumed {
+			if id != cursorStream {
+				continue
+			}
+			resumed = true
+		}
+
+		path, ok := paths[id]
+		if !ok {
+			enc.Encode(concatLine{Stream: id, Warning: "stream not found"})
+			continue
+		}
+
+		startOffset := int64(0)
+		if id == cursorStream {
+			startOffset = cursorOffset
+		}
+
+		f, err := openStream(path)
+		if err != nil {
+			enc.Encode(concatLine{Stream: id, Warning: "open failed"})
+			continue
+		}
+		if _, err := io.CopyN(io.Discard, f, startOffset); err != nil && err != io.EOF {
+			f.Close()
+			enc.Encode(concatLine{Stream: id, Warning: "seek failed"})
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		buf := make([]byte, 0, 64*1024)
+		scanner.Buffer(buf, 16*1024*1024)
+		scanner.Split(scanLinesKeepCR)
+
+		offset := startOffset
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			offset += int64(len(line) + 1)
+			data := bytes.TrimSuffix(line, []byte{'\r'})
+			if !json.Valid(data) {
+				continue
+			}
+			enc.Encode(concatLine{
+				Stream: id,
+				Offset: string(offsetFromInt(offset)),
+				Data:   json.RawMessage(data),
+			})
+			n++
+			if n%flushEvery == 0 && flusher != nil {
+				flusher.Flush()
+			}
+		}
+		f.Close()
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
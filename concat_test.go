@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// decodeConcat parses an NDJSON /_concat body.
+func decodeConcat(t *testing.T, body string) []concatLine {
+	t.Helper()
+	var out []concatLine
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		var cl concatLine
+		if err := json.Unmarshal([]byte(line), &cl); err != nil {
+			t.Fatalf("bad concat line %q: %v", line, err)
+		}
+		out = append(out, cl)
+	}
+	return out
+}
+
+func TestConcatStreams(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	aID, bID := testUUID(1), testUUID(2)
+	aLine := userEntry(testUUID(10), "from a")
+	bLine := userEntry(testUUID(11), "from b")
+	writeStream(t, projectDir, aID, aLine)
+	writeStream(t, projectDir, bID, bLine)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_concat?ids="+aID+","+bID, nil)
+	w := httptest.NewRecorder()
+	s.handleConcat(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	lines := decodeConcat(t, w.Body.String())
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if lines[0].Stream != aID || string(lines[0].Data) != aLine {
+		t.Errorf("first line = %+v, want %s from %s", lines[0], aLine, aID)
+	}
+	if lines[1].Stream != bID {
+		t.Errorf("second line from %s, want %s", lines[1].Stream, bID)
+	}
+
+	// The compound cursor resumes mid-concatenation: starting at b's
+	// beginning skips a entirely.
+	r = httptest.NewRequest("GET", "/_concat?ids="+aID+","+bID+"&cursor="+bID+":0", nil)
+	w = httptest.NewRecorder()
+	s.handleConcat(w, r)
+	lines = decodeConcat(t, w.Body.String())
+	if len(lines) != 1 || lines[0].Stream != bID {
+		t.Errorf("resumed concat = %+v, want only %s", lines, bID)
+	}
+}
+
+func TestConcatMissingStream(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	aID := testUUID(1)
+	writeStream(t, projectDir, aID, userEntry(testUUID(10), "hi"))
+	s := newTestStorage(t, claudeDir)
+
+	missing := testUUID(9)
+	r := httptest.NewRequest("GET", "/_concat?ids="+aID+","+missing, nil)
+	w := httptest.NewRecorder()
+	s.handleConcat(w, r)
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404 without skip_missing", w.Code)
+	}
+
+	// With skip_missing the unknown ID becomes a warning line instead of
+	// failing the request.
+	r = httptest.NewRequest("GET", "/_concat?ids="+aID+","+missing+"&skip_missing=1", nil)
+	w = httptest.NewRecorder()
+	s.handleConcat(w, r)
+	if w.Code != 200 {
+		t.Fatalf("skip_missing: status = %d", w.Code)
+	}
+	lines := decodeConcat(t, w.Body.String())
+	if len(lines) != 2 || lines[0].Stream != aID {
+		t.Fatalf("skip_missing lines = %+v, want data + warning", lines)
+	}
+	if lines[1].Warning == "" || lines[1].Stream != missing {
+		t.Errorf("missing stream line = %+v, want a warning", lines[1])
+	}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Shared content-block extraction for endpoints that look inside Claude
+// message entries. Message content is either a plain string or an array
+// of typed blocks ("text", "thinking", "tool_use", ...).
+
+// contentBlock is the subset of a content block the server cares about.
+type contentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// thinkingMode controls how `thinking` content blocks are treated during
+// extraction.
+type thinkingMode int
+
+const (
+	thinkingInclude thinkingMode = iota // keep thinking blocks alongside the rest
+	thinkingExclude                     // drop thinking blocks
+	thinkingOnly                        // keep only thinking blocks
+)
+
+// parseThinkingMode maps the ?thinking= query value to a mode. The
+// default differs per endpoint: raw JSON includes thinking, rendered
+// text/markdown excludes it.
+func parseThinkingMode(v string, def thinkingMode) (thinkingMode, error) {
+	switch v {
+	case "":
+		return def, nil
+	case "include":
+		return thinkingInclude, nil
+	case "exclude":
+		return thinkingExclude, nil
+	case "only":
+		return thinkingOnly, nil
+	}
+	return 0, fmt.Errorf("invalid thinking mode %q (want only, exclude or include)", v)
+}
+
+// filterThinking rewrites a raw JSONL entry so its message content blocks
+// match the given mode. Lines that don't parse, or whose content is a
+// plain string, are returned unchanged (a string has no thinking blocks,
+// so under "only" it becomes an empty block list).
+func filterThinking(line []byte, mode thinkingMode) []byte {
+	if mode == thinkingInclude {
+		return line
+	}
+
+	var entry map[string]json.RawMessage
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return line
+	}
+	rawMsg, ok := entry["message"]
+	if !ok {
+		return line
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(rawMsg, &msg); err != nil {
+		return line
+	}
+	rawContent, ok := msg["content"]
+	if !ok {
+		return line
+	}
+
+	var blocks []json.RawMessage
+	if err := json.Unmarshal(rawContent, &blocks); err != nil {
+		// String content: no thinking blocks to remove.
+		if mode == thinkingExclude {
+			return line
+		}
+		blocks = nil
+	}
+
+	filtered := make([]json.RawMessage, 0, len(blocks))
+	for _, raw := range blocks {
+		var b contentBlock
+		isThinking := json.Unmarshal(raw, &b) == nil && b.Type == "thinking"
+		if (mode == thinkingOnly) == isThinking {
+			filtered = append(filtered, raw)
+		}
+	}
+
+	newContent, err := json.Marshal(filtered)
+	if err != nil {
+		return line
+	}
+	msg["content"] = newContent
+	newMsg, err := json.Marshal(msg)
+	if err != nil {
+		return line
+	}
+	entry["message"] = newMsg
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return line
+	}
+	return out
+}
+
+// prettyJSON re-indents a compact JSON value for human inspection via
+// curl or a browser. Invalid input passes through unchanged.
+func prettyJSON(b []byte, indent string) []byte {
+	var out bytes.Buffer
+	if err := json.Indent(&out, b, indent, "  "); err != nil {
+		return b
+	}
+	return out.Bytes()
+}
+
+// normalizeContent rewrites a raw JSONL entry so message content is
+// always an array of typed blocks: plain-string content becomes a
+// single text block. Entries without a message or whose content is
+// already an array pass through unchanged, as do unparseable lines, so
+// downstream extractors only ever see one shape.
+func normalizeContent(line []byte) []byte {
+	var entry map[string]json.RawMessage
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return line
+	}
+	rawMsg, ok := entry["message"]
+	if !ok {
+		return line
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(rawMsg, &msg); err != nil {
+		return line
+	}
+	rawContent, ok := msg["content"]
+	if !ok {
+		return line
+	}
+
+	var str string
+	if err := json.Unmarshal(rawContent, &str); err != nil {
+		return line // already an array (or something else); leave it
+	}
+
+	newContent, err := json.Marshal([]contentBlock{{Type: "text", Text: str}})
+	if err != nil {
+		return line
+	}
+	msg["content"] = newContent
+	newMsg, err := json.Marshal(msg)
+	if err != nil {
+		return line
+	}
+	entry["message"] = newMsg
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return line
+	}
+	return out
+}
+
+// projectFields returns a copy of a JSON line containing only the
+// requested dot-separated paths (e.g. "type", "message.content").
+// Missing paths are simply omitted; unparseable lines pass through
+// unchanged.
+func projectFields(line []byte, paths []string) []byte {
+	var src map[string]any
+	if err := json.Unmarshal(line, &src); err != nil {
+		return line
+	}
+
+	dst := make(map[string]any)
+	for _, p := range paths {
+		copyPath(src, dst, strings.Split(p, "."))
+	}
+
+	out, err := json.Marshal(dst)
+	if err != nil {
+		return line
+	}
+	return out
+}
+
+// copyPath copies the value at the given path segments from src to dst,
+// creating intermediate objects as needed.
+func copyPath(src, dst map[string]any, segs []string) {
+	v, ok := src[segs[0]]
+	if !ok {
+		return
+	}
+	if len(segs) == 1 {
+		dst[segs[0]] = v
+		return
+	}
+	srcChild, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	dstChild, ok := dst[segs[0]].(map[string]any)
+	if !ok {
+		dstChild = make(map[string]any)
+		dst[segs[0]] = dstChild
+	}
+	copyPath(srcChild, dstChild, segs[1:])
+}
+
+// duplicateLine reports whether line repeats prev — byte-identical
+// content or the same non-empty uuid — as happens when a retried write
+// appends the same entry twice.
+func duplicateLine(line, prev []byte) bool {
+	if prev == nil {
+		return false
+	}
+	if bytes.Equal(line, prev) {
+		return true
+	}
+	var a, b struct {
+		UUID string `json:"uuid"`
+	}
+	if json.Unmarshal(line, &a) != nil || json.Unmarshal(prev, &b) != nil {
+		return false
+	}
+	return a.UUID != "" && a.UUID == b.UUID
+}
+
+// skeletonEntry reduces a JSONL entry to its envelope under ?skeleton=1:
+// identity, threading and timing fields survive, all content is dropped.
+// Unparseable lines pass through unchanged so offsets stay aligned.
+func skeletonEntry(line []byte) []byte {
+	var entry struct {
+		UUID       string `json:"uuid"`
+		ParentUUID string `json:"parentUuid"`
+		Type       string `json:"type"`
+		Timestamp  string `json:"timestamp"`
+		Message    struct {
+			Role  string `json:"role"`
+			Model string `json:"model"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return line
+	}
+
+	out, err := json.Marshal(struct {
+		UUID       string `json:"uuid,omitempty"`
+		ParentUUID string `json:"parentUuid,omitempty"`
+		Type       string `json:"type,omitempty"`
+		Role       string `json:"role,omitempty"`
+		Timestamp  string `json:"timestamp,omitempty"`
+		Model      string `json:"model,omitempty"`
+	}{entry.UUID, entry.ParentUUID, entry.Type, entry.Message.Role, entry.Timestamp, entry.Message.Model})
+	if err != nil {
+		return line
+	}
+	return out
+}
+
+// entryAnnotations are the derived UI hints attached by ?annotate=1, so
+// the front end doesn't reparse content to compute them.
+type entryAnnotations struct {
+	Role         string `json:"role,omitempty"`
+	IsError      bool   `json:"isError,omitempty"`
+	HasCode      bool   `json:"hasCode,omitempty"`
+	ApproxTokens int    `json:"approxTokens"`
+}
+
+// annotateEntry wraps a raw JSONL entry as {"entry":...,"annotations":...},
+// keeping the original content intact. Unparseable lines pass through
+// unchanged.
+func annotateEntry(line []byte) []byte {
+	var entry struct {
+		Type    string `json:"type"`
+		Message struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return line
+	}
+
+	ann := entryAnnotations{Role: entry.Message.Role}
+	if ann.Role == "" {
+		ann.Role = entry.Type
+	}
+
+	var blocks []struct {
+		Type    string          `json:"type"`
+		Text    string          `json:"text"`
+		IsError bool            `json:"is_error"`
+		Content json.RawMessage `json:"content"`
+	}
+	textLen := 0
+	if str := ""; json.Unmarshal(entry.Message.Content, &str) == nil {
+		textLen = len(str)
+		ann.HasCode = strings.Contains(str, "```")
+	} else if json.Unmarshal(entry.Message.Content, &blocks) == nil {
+		for _, b := range blocks {
+			if b.Type == "tool_result" && b.IsError {
+				ann.IsError = true
+			}
+			text := b.Text
+			if text == "" {
+				text = contentText(b.Content)
+			}
+			textLen += len(text)
+			if strings.Contains(text, "```") {
+				ann.HasCode = true
+			}
+		}
+	}
+	// Rough heuristic: English text averages about four bytes per token.
+	ann.ApproxTokens = textLen / 4
+
+	out, err := json.Marshal(struct {
+		Entry       json.RawMessage  `json:"entry"`
+		Annotations entryAnnotations `json:"annotations"`
+	}{json.RawMessage(line), ann})
+	if err != nil {
+		return line
+	}
+	return out
+}
+
+// toolActivity reports whether a raw JSONL entry is purely tool traffic
+// (tool_use or tool_result blocks), and if so returns the tool names and
+// a snippet of result text for summarization.
+func toolActivity(line []byte) (isTool bool, names []string, result string) {
+	var entry struct {
+		Message struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return false, nil, ""
+	}
+
+	var blocks []struct {
+		Type    string          `json:"type"`
+		Name    string          `json:"name"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(entry.Message.Content, &blocks); err != nil || len(blocks) == 0 {
+		return false, nil, ""
+	}
+
+	for _, b := range blocks {
+		switch b.Type {
+		case "tool_use":
+			names = append(names, b.Name)
+		case "tool_result":
+			if text := contentText(b.Content); text != "" {
+				result = text
+			}
+		default:
+			return false, nil, ""
+		}
+	}
+	return true, names, result
+}
+
+// compactToolRun is the summary entry emitted for a collapsed run of
+// tool messages. FromOffset points at the end of the message preceding
+// the run so a client can re-read the originals to expand it.
+type compactToolRun struct {
+	Type       string   `json:"type"`
+	Tools      []string `json:"tools,omitempty"`
+	Count      int      `json:"count"`
+	Preview    string   `json:"preview,omitempty"`
+	FromOffset string   `json:"fromOffset"`
+}
+
+const compactPreviewLen = 200
+
+// contentText extracts plain text from a message content field, which is
+// either a string or an array of content blocks. Thinking blocks are
+// never considered here; use filterThinking for that.
+func contentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(raw, &blocks); err == nil {
+		for _, b := range blocks {
+			if b.Type == "text" && b.Text != "" {
+				return b.Text
+			}
+		}
+	}
+	return ""
+}
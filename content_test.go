@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// thinkingLine is an assistant entry with one thinking and one text block.
+func thinkingLine() []byte {
+	return []byte(fmt.Sprintf(`{"type":"assistant","uuid":%q,"message":{"role":"assistant","content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"visible"}]}}`,
+		testUUID(1)))
+}
+
+// blockTypes returns the content block types of a filtered line.
+func blockTypes(t *testing.T, line []byte) []string {
+	t.Helper()
+	var entry struct {
+		Message struct {
+			Content []contentBlock `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatalf("filtered line does not parse: %v\n%s", err, line)
+	}
+	types := make([]string, len(entry.Message.Content))
+	for i, b := range entry.Message.Content {
+		types[i] = b.Type
+	}
+	return types
+}
+
+func TestFilterThinkingModes(t *testing.T) {
+	line := thinkingLine()
+
+	// include passes the line through untouched.
+	if got := filterThinking(line, thinkingInclude); string(got) != string(line) {
+		t.Errorf("include rewrote the line:\n%s", got)
+	}
+
+	if types := blockTypes(t, filterThinking(line, thinkingExclude)); len(types) != 1 || types[0] != "text" {
+		t.Errorf("exclude kept blocks %v, want [text]", types)
+	}
+
+	if types := blockTypes(t, filterThinking(line, thinkingOnly)); len(types) != 1 || types[0] != "thinking" {
+		t.Errorf("only kept blocks %v, want [thinking]", types)
+	}
+
+	// String content has no thinking blocks: exclude passes it through,
+	// only yields an empty block list.
+	strLine := []byte(userEntry(testUUID(2), "plain"))
+	if got := filterThinking(strLine, thinkingExclude); string(got) != string(strLine) {
+		t.Errorf("exclude rewrote string content:\n%s", got)
+	}
+	if types := blockTypes(t, filterThinking(strLine, thinkingOnly)); len(types) != 0 {
+		t.Errorf("only on string content kept blocks %v, want none", types)
+	}
+}
+
+func TestParseThinkingMode(t *testing.T) {
+	if m, err := parseThinkingMode("", thinkingExclude); err != nil || m != thinkingExclude {
+		t.Errorf("empty value: got %v, %v; want the default", m, err)
+	}
+	if _, err := parseThinkingMode("bogus", thinkingInclude); err == nil {
+		t.Error("bogus mode accepted")
+	}
+}
+
+func TestProjectFields(t *testing.T) {
+	line := []byte(`{"type":"user","uuid":"u1","timestamp":"2026-01-01T00:00:00Z","message":{"role":"user","content":"hi","model":"m"}}`)
+
+	out := projectFields(line, []string{"type", "message.content"})
+	var got map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("projected line does not parse: %v", err)
+	}
+	if got["type"] != "user" {
+		t.Errorf("type = %v", got["type"])
+	}
+	msg, ok := got["message"].(map[string]any)
+	if !ok || msg["content"] != "hi" {
+		t.Errorf("message.content missing: %v", got)
+	}
+	if _, ok := msg["role"]; ok {
+		t.Error("unrequested message.role survived projection")
+	}
+	if _, ok := got["timestamp"]; ok {
+		t.Error("unrequested timestamp survived projection")
+	}
+
+	// Missing paths are omitted, not errors.
+	out = projectFields(line, []string{"nope.nested"})
+	var empty map[string]any
+	if err := json.Unmarshal(out, &empty); err != nil || len(empty) != 0 {
+		t.Errorf("missing path: got %s, want {}", out)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCORSPreflightCaching(t *testing.T) {
+	handler := corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot) // must not be reached on OPTIONS
+	}))
+
+	r := httptest.NewRequest("OPTIONS", "/_metadata", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("preflight status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != strconv.Itoa(corsMaxAge) {
+		t.Errorf("Access-Control-Max-Age = %q, want %d", got, corsMaxAge)
+	}
+	// Batch metadata needs POST and the bookmark store PUT; the allowed
+	// methods must advertise them.
+	methods := w.Header().Get("Access-Control-Allow-Methods")
+	for _, m := range []string{"GET", "POST", "PUT", "OPTIONS"} {
+		if !strings.Contains(methods, m) {
+			t.Errorf("allowed methods %q missing %s", methods, m)
+		}
+	}
+
+	// Non-preflight requests pass through without the max-age header.
+	r = httptest.NewRequest("GET", "/_streams", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusTeapot {
+		t.Errorf("GET did not reach the wrapped handler: status %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Max-Age") != "" {
+		t.Error("max-age set outside a preflight response")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// writeCRLFStream writes lines as <dir>/<id>.jsonl with Windows line
+// endings and returns the path.
+func writeCRLFStream(t *testing.T, dir, id string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, id+".jsonl")
+	data := strings.Join(lines, "\r\n") + "\r\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadCRLFOffsetsCountEveryByte(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := []string{
+		userEntry(testUUID(2), "carriage one"),
+		userEntry(testUUID(3), "carriage two"),
+	}
+	writeCRLFStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := context.Background()
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(result.Messages))
+	}
+
+	// Data is trimmed of the \r, but offsets count the raw bytes —
+	// each line contributes len+2 — or resume cursors drift one byte
+	// per line and eventually split a message.
+	for i, msg := range result.Messages {
+		if string(msg.Data) != lines[i] {
+			t.Errorf("message %d data = %q, want %q", i, msg.Data, lines[i])
+		}
+	}
+	firstEnd := int64(len(lines[0]) + 2)
+	if got := offsetToInt(result.Messages[0].Offset); got != firstEnd {
+		t.Errorf("first message offset = %d, want %d", got, firstEnd)
+	}
+	fileEnd := firstEnd + int64(len(lines[1])+2)
+	if got := offsetToInt(result.NextOffset); got != fileEnd {
+		t.Errorf("NextOffset = %d, want file size %d", got, fileEnd)
+	}
+
+	// Resuming from the first message's offset lands exactly on the
+	// second line.
+	result, err = s.Read(ctx, id, result.Messages[0].Offset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0].Data) != lines[1] {
+		t.Fatalf("resumed read = %+v, want just the second line", result.Messages)
+	}
+}
+
+func TestGrepCRLFOffsets(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := []string{
+		userEntry(testUUID(2), "plain line"),
+		userEntry(testUUID(3), "the needle"),
+	}
+	writeCRLFStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_grep/"+id+"?q=needle", nil)
+	w := httptest.NewRecorder()
+	s.handleGrep(w, r)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var page struct {
+		Items []grepMatch `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 {
+		t.Fatalf("got %d matches, want 1", len(page.Items))
+	}
+	want := int64(len(lines[0]) + 2 + len(lines[1]) + 2)
+	if got := offsetToInt(durablestream.Offset(page.Items[0].Line.Offset)); got != want {
+		t.Errorf("match offset = %d, want %d (raw bytes incl. \\r)", got, want)
+	}
+}
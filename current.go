@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+)
+
+// handleCurrent reports the most-recently-written conversation — the
+// session the user is most likely sitting in — so a UI can auto-open it.
+// Before any write has been observed this falls back to the newest file
+// in the index.
+//
+// GET /_current
+func (s *ClaudeStorage) handleCurrent(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	id, at := s.currentID, s.currentAt
+	index := make(map[string]string, len(s.fileIndex))
+	for sid, path := range s.fileIndex {
+		index[sid] = path
+	}
+	s.mu.RUnlock()
+
+	if id == "" {
+		// No writes seen yet this run; pick the newest indexed file.
+		for sid, path := range index {
+			if sid == "_history" {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if info.ModTime().After(at) {
+				id, at = sid, info.ModTime()
+			}
+		}
+	}
+	if id == "" {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "no conversations yet")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		ID           string    `json:"id"`
+		LastModified time.Time `json:"lastModified"`
+	}{id, at})
+}
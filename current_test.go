@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCurrentTracksLastWrite(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	aID, bID := testUUID(1), testUUID(2)
+	writeStream(t, projectDir, aID, userEntry(testUUID(10), "a"))
+	bPath := writeStream(t, projectDir, bID, userEntry(testUUID(11), "b"))
+	s := newTestStorage(t, claudeDir)
+
+	// Simulate the watch loop observing a write to b.
+	s.notifyChange(bPath)
+
+	r := httptest.NewRequest("GET", "/_current", nil)
+	w := httptest.NewRecorder()
+	s.handleCurrent(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != bID {
+		t.Errorf("current = %s, want the last-written stream %s", resp.ID, bID)
+	}
+}
+
+func TestCurrentFallsBackToNewestFile(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	oldID, newID := testUUID(1), testUUID(2)
+	oldPath := writeStream(t, projectDir, oldID, userEntry(testUUID(10), "old"))
+	base := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(oldPath, base, base); err != nil {
+		t.Fatal(err)
+	}
+	writeStream(t, projectDir, newID, userEntry(testUUID(11), "new"))
+	s := newTestStorage(t, claudeDir)
+
+	// No write observed this run: mod-time decides.
+	r := httptest.NewRequest("GET", "/_current", nil)
+	w := httptest.NewRecorder()
+	s.handleCurrent(w, r)
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != newID {
+		t.Errorf("current = %s, want the newest file %s", resp.ID, newID)
+	}
+}
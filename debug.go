@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debugEvent is one fsnotify event as seen by watchLoop, with the stream
+// ID it was mapped to.
+type debugEvent struct {
+	Path     string `json:"path"`
+	Op       string `json:"op"`
+	StreamID string `json:"streamId"`
+}
+
+// publishDebugEvent fans an event out to any /_debug/events listeners.
+// Sends never block the watch loop; slow listeners just miss events.
+func (s *ClaudeStorage) publishDebugEvent(ev fsnotify.Event) {
+	s.mu.RLock()
+	subs := s.debugSubs
+	s.mu.RUnlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	streamID := strings.TrimSuffix(filepath.Base(ev.Name), ".jsonl")
+	if ev.Name == filepath.Join(s.claudeDir, "history.jsonl") {
+		streamID = "_history"
+	}
+	de := debugEvent{Path: ev.Name, Op: ev.Op.String(), StreamID: streamID}
+
+	for _, ch := range subs {
+		select {
+		case ch <- de:
+		default:
+		}
+	}
+}
+
+// handleDebugIndex dumps the current stream index (ID -> resolved path)
+// and the subscriber count per stream, the first thing to check when a
+// conversation isn't showing up or live updates aren't being delivered.
+//
+// GET /_debug/index   (dev mode or admin only)
+func (s *ClaudeStorage) handleDebugIndex(w http.ResponseWriter, r *http.Request) {
+	type indexEntry struct {
+		Path        string `json:"path"`
+		Subscribers int    `json:"subscribers,omitempty"`
+	}
+
+	s.mu.RLock()
+	index := make(map[string]indexEntry, len(s.fileIndex))
+	for id, path := range s.fileIndex {
+		index[id] = indexEntry{Path: path, Subscribers: len(s.subscribers[id])}
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Index map[string]indexEntry `json:"index"`
+	}{index})
+}
+
+// handleDebugEvents streams raw watcher events over SSE, for diagnosing
+// why live updates aren't firing on a user's filesystem.
+//
+// GET /_debug/events   (dev mode or admin only)
+func (s *ClaudeStorage) handleDebugEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apiError(w, http.StatusInternalServerError, codeInternal, "streaming unsupported")
+		return
+	}
+
+	ch := make(chan debugEvent, 64)
+	s.mu.Lock()
+	s.debugSubs = append(s.debugSubs, ch)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		for i, c := range s.debugSubs {
+			if c == ch {
+				s.debugSubs = append(s.debugSubs[:i], s.debugSubs[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher.Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.shutdownCh:
+			fmt.Fprintf(w, "event: shutdown\ndata: {\"reason\":\"server shutting down\"}\n\n")
+			flusher.Flush()
+			return
+		case ev := <-ch:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: fsnotify\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugIndexListsStreams(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "indexed"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_debug/index", nil)
+	w := httptest.NewRecorder()
+	s.handleDebugIndex(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Index map[string]struct {
+			Path        string `json:"path"`
+			Subscribers int    `json:"subscribers"`
+		} `json:"index"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := resp.Index[id]
+	if !ok {
+		t.Fatalf("stream %s missing from index dump: %v", id, resp.Index)
+	}
+	if entry.Path != path {
+		t.Errorf("path = %s, want %s", entry.Path, path)
+	}
+	if entry.Subscribers != 0 {
+		t.Errorf("subscribers = %d, want 0 with no clients attached", entry.Subscribers)
+	}
+}
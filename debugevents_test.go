@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugEventsStreamWatcherActivity(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "watch me"))
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/_debug/events", nil).WithContext(ctx)
+	w := &lockedRecorder{rec: httptest.NewRecorder()}
+	done := make(chan struct{})
+	go func() {
+		s.handleDebugEvents(w, r)
+		close(done)
+	}()
+
+	// Wait for the tap to register before generating an event.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.RLock()
+		n := len(s.debugSubs)
+		s.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("debug tap never registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	appendLine(t, path, userEntry(testUUID(3), "generates an event"))
+
+	deadline = time.Now().Add(3 * time.Second)
+	for !strings.Contains(w.body(), "event: fsnotify") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no fsnotify event streamed; body:\n%s", w.body())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	body := w.body()
+	if !strings.Contains(body, id) {
+		t.Errorf("event missing derived stream ID %s:\n%s", id, body)
+	}
+	if !strings.Contains(body, path) {
+		t.Errorf("event missing path %s:\n%s", path, body)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestDuplicateLine(t *testing.T) {
+	a := []byte(`{"uuid":"u1","message":{"content":"hi"}}`)
+	sameUUID := []byte(`{"uuid":"u1","message":{"content":"hi again"}}`)
+	other := []byte(`{"uuid":"u2","message":{"content":"hi"}}`)
+
+	if !duplicateLine(a, a) {
+		t.Errorf("byte-identical line not flagged")
+	}
+	if !duplicateLine(sameUUID, a) {
+		t.Errorf("same-uuid retry not flagged")
+	}
+	if duplicateLine(other, a) {
+		t.Errorf("distinct line flagged as duplicate")
+	}
+	if duplicateLine(a, nil) {
+		t.Errorf("first line flagged with no predecessor")
+	}
+}
+
+func TestReadDedupSuppressesRetries(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	dup := userEntry(testUUID(2), "written twice")
+	last := userEntry(testUUID(3), "unique")
+	writeStream(t, projectDir, id, dup, dup, last)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := withDedup(context.Background())
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("got %d messages, want the duplicate dropped", len(result.Messages))
+	}
+	if string(result.Messages[0].Data) != dup || string(result.Messages[1].Data) != last {
+		t.Errorf("deduped messages = %s / %s", result.Messages[0].Data, result.Messages[1].Data)
+	}
+
+	// Offsets still reflect file positions: the surviving lines sit at
+	// the ends of lines 1 and 3, and the tail covers all three.
+	if got := offsetToInt(result.Messages[0].Offset); got != int64(len(dup)+1) {
+		t.Errorf("first offset = %d, want %d", got, len(dup)+1)
+	}
+	total := int64(2*(len(dup)+1) + len(last) + 1)
+	if got := offsetToInt(result.Messages[1].Offset); got != total {
+		t.Errorf("last offset = %d, want %d", got, total)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectClaudeDir(t *testing.T) {
+	claudeDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(claudeDir, "projects"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CLAUDE_HOME", claudeDir)
+
+	got, err := detectClaudeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != claudeDir {
+		t.Errorf("detected %s, want $CLAUDE_HOME %s", got, claudeDir)
+	}
+}
+
+func TestDetectClaudeDirNoneFound(t *testing.T) {
+	// Point every candidate source at empty directories so nothing has a
+	// projects subdirectory.
+	empty := t.TempDir()
+	t.Setenv("CLAUDE_HOME", empty)
+	t.Setenv("HOME", empty)
+	t.Setenv("XDG_CONFIG_HOME", empty)
+
+	_, err := detectClaudeDir()
+	if err == nil {
+		t.Fatal("expected an error when no candidate qualifies")
+	}
+	// The message lists what was checked so users can fix their setup.
+	if !strings.Contains(err.Error(), empty) {
+		t.Errorf("error %q does not list the checked paths", err)
+	}
+}
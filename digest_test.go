@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestReadDigestDetectsRewrite(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "original content"))
+	s := newTestStorage(t, claudeDir)
+
+	readDigestAt := func() string {
+		d := &readDigest{}
+		ctx := withReadDigest(context.Background(), d)
+		if _, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes); err != nil {
+			t.Fatal(err)
+		}
+		if !d.set {
+			t.Fatal("digest not computed")
+		}
+		return d.String()
+	}
+
+	first := readDigestAt()
+	if again := readDigestAt(); again != first {
+		t.Errorf("digest not stable across identical reads: %s vs %s", first, again)
+	}
+
+	// Rewrite the file with different bytes of the same shape: the
+	// digest at the same offset must change.
+	if err := os.WriteFile(path, []byte(userEntry(testUUID(2), "rewritten content")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if after := readDigestAt(); after == first {
+		t.Errorf("digest unchanged after rewrite: %s", after)
+	}
+}
+
+func TestDigestPrecondition(t *testing.T) {
+	d := &readDigest{sum: 0xdeadbeef, set: true}
+
+	// A matching Stream-If-Digest passes and the digest is echoed.
+	rec := httptest.NewRecorder()
+	w := &digestHeaderWriter{ResponseWriter: rec, digest: d, ifDigest: d.String()}
+	w.Write([]byte("{}"))
+	if rec.Code != 200 {
+		t.Errorf("matching digest: status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Stream-Digest"); got != d.String() {
+		t.Errorf("Stream-Digest = %q, want %s", got, d.String())
+	}
+
+	// A stale digest turns the response into a 412 and drops the body.
+	rec = httptest.NewRecorder()
+	w = &digestHeaderWriter{ResponseWriter: rec, digest: d, ifDigest: "crc32:00000000"}
+	w.Write([]byte("stale payload"))
+	if rec.Code != 412 {
+		t.Errorf("mismatched digest: status = %d, want 412", rec.Code)
+	}
+	if code, _ := decodeAPIError(t, rec.Body.Bytes()); code != codePreconditionFailed {
+		t.Errorf("error code = %s, want %s", code, codePreconditionFailed)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// The list endpoints share the {items, next_cursor, total} envelope.
+
+func TestChangedEnvelope(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "hello"))
+	s := newTestStorage(t, claudeDir)
+
+	since := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	r := httptest.NewRequest("GET", "/_changed?since="+since, nil)
+	w := httptest.NewRecorder()
+	s.handleChanged(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Items []changedStream `json:"items"`
+		Total int             `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || len(resp.Items) != 1 {
+		t.Fatalf("total = %d, items = %d, want 1 changed stream", resp.Total, len(resp.Items))
+	}
+	if resp.Items[0].ID != id {
+		t.Errorf("changed stream = %s, want %s", resp.Items[0].ID, id)
+	}
+}
+
+func TestGrepEnvelopeCursor(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+
+	// More matching lines than the cap, so the first page truncates and
+	// hands back a cursor.
+	lines := make([]string, maxGrepMatches+5)
+	for i := range lines {
+		lines[i] = userEntry(testUUID(i+2), "needle")
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_grep/"+id+"?q=needle", nil)
+	w := httptest.NewRecorder()
+	s.handleGrep(w, r)
+
+	var page struct {
+		Items      []grepMatch `json:"items"`
+		NextCursor string      `json:"next_cursor"`
+		Total      int         `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != maxGrepMatches || page.Total != maxGrepMatches {
+		t.Fatalf("got %d items (total %d), want %d", len(page.Items), page.Total, maxGrepMatches)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("truncated page has no next_cursor")
+	}
+
+	// The second page picks up the remaining matches.
+	r = httptest.NewRequest("GET", "/_grep/"+id+"?q=needle&cursor="+page.NextCursor, nil)
+	w = httptest.NewRecorder()
+	s.handleGrep(w, r)
+	var page2 struct {
+		Items      []grepMatch `json:"items"`
+		NextCursor string      `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page2); err != nil {
+		t.Fatal(err)
+	}
+	if len(page2.Items) != 5 {
+		t.Fatalf("second page has %d items, want 5", len(page2.Items))
+	}
+	if page2.NextCursor != "" {
+		t.Errorf("last page has next_cursor %q, want empty", page2.NextCursor)
+	}
+}
+
+func TestProjectsTreeEnvelope(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	writeStream(t, projectDir, testUUID(1), userEntry(testUUID(2), "hello"))
+	writeStream(t, projectDir, testUUID(3), userEntry(testUUID(4), "again"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_projects/tree", nil)
+	w := httptest.NewRecorder()
+	s.handleProjectsTree(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Items []*projectNode `json:"items"`
+		Total int            `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 2 {
+		t.Errorf("total = %d, want 2", resp.Total)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Name != "-test-project" {
+		t.Fatalf("items = %+v, want the single -test-project node", resp.Items)
+	}
+	if resp.Items[0].Count != 2 {
+		t.Errorf("project count = %d, want 2", resp.Items[0].Count)
+	}
+}
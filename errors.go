@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Machine-readable error codes shared by all custom endpoints, so
+// clients can branch on codes instead of parsing messages.
+const (
+	codeStreamNotFound     = "stream_not_found"
+	codeMessageNotFound    = "message_not_found"
+	codeReadOnly           = "read_only"
+	codeBadRequest         = "bad_request"
+	codeUnauthorized       = "unauthorized"
+	codeForbidden          = "forbidden"
+	codeMethodNotAllow     = "method_not_allowed"
+	codeStreamTooLarge     = "stream_too_large"
+	codeNotJSONL           = "not_jsonl"
+	codePreconditionFailed = "precondition_failed"
+	codeBookmarkNotFound   = "bookmark_not_found"
+	codeInternal           = "internal"
+)
+
+// apiError writes the standard error envelope:
+//
+//	{"error":{"code":"stream_not_found","message":"..."}}
+func apiError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
+// apiErrorFrom maps storage-layer errors onto the envelope.
+func apiErrorFrom(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrStreamNotFound):
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+	case errors.Is(err, ErrReadOnly):
+		apiError(w, http.StatusMethodNotAllowed, codeReadOnly, "storage is read-only")
+	case errors.Is(err, ErrSubscribeDisabled):
+		apiError(w, http.StatusForbidden, codeForbidden, "subscriptions disabled for this stream")
+	case errors.Is(err, ErrMessageNotFound):
+		apiError(w, http.StatusNotFound, codeMessageNotFound, "message not found")
+	case errors.Is(err, ErrStreamTooLarge):
+		apiError(w, http.StatusRequestEntityTooLarge, codeStreamTooLarge,
+			"stream exceeds -max-stream-bytes; pass ?force=1 to read it anyway")
+	case errors.Is(err, ErrNotJSONL):
+		apiError(w, http.StatusUnprocessableEntity, codeNotJSONL,
+			"file is not JSONL (first line is not valid JSON)")
+	default:
+		apiError(w, http.StatusInternalServerError, codeInternal, err.Error())
+	}
+}
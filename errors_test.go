@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// decodeAPIError unwraps the standard {"error":{code,message}} envelope.
+func decodeAPIError(t *testing.T, body []byte) (code, message string) {
+	t.Helper()
+	var resp struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("error response is not the envelope: %v\n%s", err, body)
+	}
+	return resp.Error.Code, resp.Error.Message
+}
+
+func TestAPIErrorEnvelope(t *testing.T) {
+	w := httptest.NewRecorder()
+	apiError(w, 400, codeBadRequest, "missing q parameter")
+	if w.Code != 400 {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+	code, msg := decodeAPIError(t, w.Body.Bytes())
+	if code != codeBadRequest || msg != "missing q parameter" {
+		t.Errorf("envelope = %s / %s", code, msg)
+	}
+}
+
+func TestAPIErrorFromSentinels(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{ErrStreamNotFound, 404, codeStreamNotFound},
+		{ErrReadOnly, 405, codeReadOnly},
+		{ErrSubscribeDisabled, 403, codeForbidden},
+		{ErrMessageNotFound, 404, codeMessageNotFound},
+		{ErrStreamTooLarge, 413, codeStreamTooLarge},
+		{ErrNotJSONL, 422, codeNotJSONL},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		apiErrorFrom(w, tc.err)
+		if w.Code != tc.status {
+			t.Errorf("%v: status = %d, want %d", tc.err, w.Code, tc.status)
+		}
+		if code, _ := decodeAPIError(t, w.Body.Bytes()); code != tc.code {
+			t.Errorf("%v: code = %s, want %s", tc.err, code, tc.code)
+		}
+	}
+}
+
+// Handlers surface the envelope too, not just the helpers.
+func TestHandlerUnknownStream(t *testing.T) {
+	claudeDir, _ := newTestClaudeDir(t)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_grep/"+testUUID(9)+"?q=x", nil)
+	w := httptest.NewRecorder()
+	s.handleGrep(w, r)
+	if w.Code != 404 {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+	if code, _ := decodeAPIError(t, w.Body.Bytes()); code != codeStreamNotFound {
+		t.Errorf("code = %s, want %s", code, codeStreamNotFound)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hashCache memoizes content hashes keyed by file path. Entries are
+// invalidated when size or mtime changes, so the streaming hash is only
+// recomputed after a write.
+type hashCache struct {
+	mu      sync.Mutex
+	entries map[string]hashCacheEntry
+}
+
+type hashCacheEntry struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// contentETag returns a strong ETag derived from a SHA-256 of the file
+// contents. Unlike offset/mtime ETags it is stable across restarts and
+// file touches that don't change content.
+func (s *ClaudeStorage) contentETag(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	s.hashes.mu.Lock()
+	if e, ok := s.hashes.entries[path]; ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		s.hashes.mu.Unlock()
+		return e.sum, nil
+	}
+	s.hashes.mu.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum := fmt.Sprintf(`"%s"`, hex.EncodeToString(h.Sum(nil)))
+
+	s.hashes.mu.Lock()
+	s.hashes.entries[path] = hashCacheEntry{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		sum:     sum,
+	}
+	s.hashes.mu.Unlock()
+
+	return sum, nil
+}
+
+// handleDownload serves the raw JSONL file for a conversation with a
+// content-based ETag so finished conversations cache well.
+//
+// GET /_download/{streamID}
+func (s *ClaudeStorage) handleDownload(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_download/")
+
+	path, err := s.getPath(streamID)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	etag, err := s.contentETag(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "hash stream")
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Gzip-compressed conversations are decompressed here so the body
+	// matches the NDJSON content type and .jsonl filename below.
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	// Conversations compress well, so offer on-the-fly gzip.
+	if r.URL.Query().Get("compress") == "gzip" {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", streamID+".jsonl.gz"))
+
+		gz := gzipPool.Get().(*gzip.Writer)
+		gz.Reset(w)
+		io.Copy(gz, f)
+		gz.Close()
+		gzipPool.Put(gz)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", streamID+".jsonl"))
+	io.Copy(w, f)
+}
+
+// gzipPool reuses gzip writers across downloads to limit allocation.
+var gzipPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestContentETagStable(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "hello"))
+	s := newTestStorage(t, claudeDir)
+
+	first, err := s.contentETag(path)
+	if err != nil {
+		t.Fatalf("contentETag: %v", err)
+	}
+	again, err := s.contentETag(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != again {
+		t.Errorf("ETag changed across reads: %q vs %q", first, again)
+	}
+
+	// Appending changes the content, so the ETag must change too. Nudge
+	// the mtime in case the append lands within filesystem granularity.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(userEntry(testUUID(3), "more") + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	future := time.Now().Add(2 * time.Second)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := s.contentETag(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == first {
+		t.Error("ETag unchanged after modification")
+	}
+}
+
+func TestDownloadETagNotModified(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "hello"))
+	s := newTestStorage(t, claudeDir)
+
+	etag, err := s.contentETag(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "/_download/"+id, nil)
+	r.Header.Set("If-None-Match", etag)
+	w := httptest.NewRecorder()
+	s.handleDownload(w, r)
+	if w.Code != 304 {
+		t.Errorf("status = %d, want 304 for a matching If-None-Match", w.Code)
+	}
+}
+
+func TestDownloadCompressGzip(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := userEntry(testUUID(2), "compress me")
+	writeStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_download/"+id+"?compress=gzip", nil)
+	w := httptest.NewRecorder()
+	s.handleDownload(w, r)
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != line+"\n" {
+		t.Errorf("decompressed body = %q, want %q", body, line+"\n")
+	}
+}
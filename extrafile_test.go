@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestRegisterExtraFile(t *testing.T) {
+	claudeDir, _ := newTestClaudeDir(t)
+	s := newTestStorage(t, claudeDir)
+
+	outside := t.TempDir()
+	line := userEntry(testUUID(2), "lives outside the tree")
+	path := writeStream(t, outside, "export", line)
+
+	if err := s.registerExtraFile("my-export", path); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	result, err := s.Read(ctx, "my-export", durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0].Data) != line {
+		t.Fatalf("read = %+v, want the external file's line", result.Messages)
+	}
+
+	// The external file's directory is watched: appends notify
+	// subscribers under the chosen ID, not the basename.
+	ch, err := s.Subscribe(ctx, "my-export", result.NextOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendLine(t, path, userEntry(testUUID(3), "appended outside"))
+	select {
+	case _, ok := <-ch:
+		if !ok {
+			t.Fatal("subscription closed early")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("append to extra file never reached the subscriber")
+	}
+
+	// Relative paths and ID collisions are rejected.
+	if err := s.registerExtraFile("rel", "not/absolute.jsonl"); err == nil {
+		t.Error("relative path accepted")
+	}
+	other := writeStream(t, outside, "other", userEntry(testUUID(4), "x"))
+	if err := s.registerExtraFile("my-export", other); err == nil {
+		t.Error("duplicate stream ID accepted")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// handleFiltered exports the subset of a conversation matching the
+// given filters as a valid JSONL file — not a JSON array — so the
+// result can be archived or re-imported. Matching lines are emitted
+// byte-identical to the originals.
+//
+// GET /_filtered/{streamID}?type=assistant&since=RFC3339
+func (s *ClaudeStorage) handleFiltered(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_filtered/")
+
+	var types map[string]bool
+	if v := r.URL.Query().Get("type"); v != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(v, ",") {
+			types[t] = true
+		}
+	}
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid since timestamp (want RFC3339)")
+			return
+		}
+	}
+
+	path, err := s.getPathCtx(r.Context(), streamID)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", streamID+"-filtered.jsonl"))
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !json.Valid(line) {
+			continue
+		}
+
+		var entry struct {
+			Type      string `json:"type"`
+			Timestamp string `json:"timestamp"`
+		}
+		json.Unmarshal(line, &entry)
+		if types != nil && !types[entry.Type] {
+			continue
+		}
+		if !since.IsZero() {
+			ts, err := time.Parse(time.RFC3339, entry.Timestamp)
+			if err != nil || ts.Before(since) {
+				continue
+			}
+		}
+
+		w.Write(line)
+		io.WriteString(w, "\n")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// typedTimedEntry builds a JSONL line with an arbitrary entry type and
+// timestamp, for exercising the type/since filters together.
+func typedTimedEntry(typ, uuid, text, ts string) string {
+	return fmt.Sprintf(`{"type":%q,"uuid":%q,"timestamp":%q,"message":{"role":%q,"content":%q}}`,
+		typ, uuid, ts, typ, text)
+}
+
+func TestFilteredExport(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	user1 := typedTimedEntry("user", testUUID(2), "early question", "2026-01-01T10:00:00Z")
+	asst1 := typedTimedEntry("assistant", testUUID(3), "early answer", "2026-01-01T10:01:00Z")
+	asst2 := typedTimedEntry("assistant", testUUID(4), "late answer", "2026-01-02T10:00:00Z")
+	writeStream(t, projectDir, id, user1, asst1, asst2)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_filtered/"+id+"?type=assistant", nil)
+	w := httptest.NewRecorder()
+	s.handleFiltered(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("content type = %s", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "attachment") {
+		t.Errorf("disposition = %s", cd)
+	}
+	// Matching lines come back byte-identical to the originals.
+	if got, want := w.Body.String(), asst1+"\n"+asst2+"\n"; got != want {
+		t.Errorf("filtered body = %q, want %q", got, want)
+	}
+
+	// Combining type and since narrows further.
+	r = httptest.NewRequest("GET", "/_filtered/"+id+"?type=assistant&since=2026-01-02T00:00:00Z", nil)
+	w = httptest.NewRecorder()
+	s.handleFiltered(w, r)
+	if got, want := w.Body.String(), asst2+"\n"; got != want {
+		t.Errorf("since-filtered body = %q, want %q", got, want)
+	}
+}
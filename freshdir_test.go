@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProjectsDirAppearingAfterStartup(t *testing.T) {
+	// Fresh machine: the storage starts with no projects directory and
+	// must notice it being created later.
+	claudeDir := t.TempDir()
+	s := newTestStorage(t, claudeDir)
+
+	projectDir := filepath.Join(claudeDir, "projects", "-first-project")
+	if err := os.MkdirAll(filepath.Join(claudeDir, "projects"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Give the new watches a moment to land between each layer.
+	time.Sleep(100 * time.Millisecond)
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "first ever session"))
+	waitForIndex(t, s, id)
+}
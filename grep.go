@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxGrepContext caps the ?context= window either side of a match.
+const maxGrepContext = 10
+
+// maxGrepMatches caps how many matches one request returns; past it the
+// response is flagged truncated.
+const maxGrepMatches = 100
+
+// grepSnippetLen bounds the extracted text per line.
+const grepSnippetLen = 200
+
+// grepLine is one line of grep output: its offset (just past the line,
+// like Read) and a readable snippet of its content.
+type grepLine struct {
+	Offset  string `json:"offset"`
+	Type    string `json:"type,omitempty"`
+	Snippet string `json:"snippet"`
+}
+
+// grepMatch is a matching line with its surrounding context window.
+type grepMatch struct {
+	Line   grepLine   `json:"line"`
+	Before []grepLine `json:"before,omitempty"`
+	After  []grepLine `json:"after,omitempty"`
+}
+
+// handleGrep searches one conversation for a substring, returning each
+// matching line with N messages of context either side — the drill-in
+// companion to listing-level search. Results use the shared list
+// envelope: when the scan stops early (match cap or timeout) next_cursor
+// resumes it just past the last examined line. Total counts this page's
+// matches; a bounded scan can't know the full count.
+//
+// GET /_grep/{streamID}?q=needle[&context=N][&cursor=...]
+func (s *ClaudeStorage) handleGrep(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_grep/")
+
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "missing q parameter")
+		return
+	}
+	contextN := 0
+	if v := r.URL.Query().Get("context"); v != "" {
+		var err error
+		contextN, err = strconv.Atoi(v)
+		if err != nil || contextN < 0 || contextN > maxGrepContext {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid context (want 0-10)")
+			return
+		}
+	}
+	var startOffset int64
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		pos, err := decodeCursor(c)
+		if err == nil {
+			startOffset, err = strconv.ParseInt(pos, 10, 64)
+		}
+		if err != nil || startOffset < 0 {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid cursor")
+			return
+		}
+	}
+
+	path, err := s.getPathCtx(r.Context(), streamID)
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	// A cursor names the byte offset to resume from; the skipped prefix
+	// is discarded rather than seeked since the stream may be gzipped.
+	if _, err := io.CopyN(io.Discard, f, startOffset); err != nil && err != io.EOF {
+		apiError(w, http.StatusInternalServerError, codeInternal, "seek")
+		return
+	}
+
+	ctx, cancel := scanContext(r.Context())
+	defer cancel()
+
+	var (
+		matches   = []grepMatch{} // non-nil so items encodes as []
+		before    []grepLine      // ring of the last contextN lines
+		wantAfter []int           // indexes into matches still filling After
+		truncated bool
+		offset    = startOffset
+		needle    = strings.ToLower(q)
+		lines     = 0
+	)
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+	scanner.Split(scanLinesKeepCR)
+
+	for scanner.Scan() {
+		lines++
+		if lines%ctxCheckEvery == 0 && ctx.Err() != nil {
+			truncated = true
+			break
+		}
+
+		line := scanner.Bytes()
+		offset += int64(len(line) + 1)
+		data := bytes.TrimSuffix(line, []byte{'\r'})
+		if !json.Valid(data) {
+			continue
+		}
+
+		gl := grepLine{Offset: string(offsetFromInt(offset))}
+		var entry struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(data, &entry)
+		gl.Type = entry.Type
+		if text := contentText(extractMessageContent(data)); text != "" {
+			gl.Snippet = truncate(text, grepSnippetLen)
+		} else {
+			gl.Snippet = truncate(string(data), grepSnippetLen)
+		}
+
+		// Fill pending After windows before considering this line as a
+		// match of its own.
+		remaining := wantAfter[:0]
+		for _, mi := range wantAfter {
+			matches[mi].After = append(matches[mi].After, gl)
+			if len(matches[mi].After) < contextN {
+				remaining = append(remaining, mi)
+			}
+		}
+		wantAfter = remaining
+
+		if strings.Contains(strings.ToLower(string(data)), needle) {
+			if len(matches) >= maxGrepMatches {
+				// This match doesn't fit the page; resume from its start.
+				offset -= int64(len(line) + 1)
+				truncated = true
+				break
+			}
+			m := grepMatch{Line: gl}
+			m.Before = append(m.Before, before...)
+			matches = append(matches, m)
+			if contextN > 0 {
+				wantAfter = append(wantAfter, len(matches)-1)
+			}
+		}
+
+		if contextN > 0 {
+			before = append(before, gl)
+			if len(before) > contextN {
+				before = before[1:]
+			}
+		}
+	}
+	// An I/O error or over-long line would otherwise masquerade as a
+	// clean end of results with no resume cursor.
+	if err := scanner.Err(); err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "scan stream")
+		return
+	}
+
+	nextCursor := ""
+	if truncated {
+		nextCursor = encodeCursor(strconv.FormatInt(offset, 10))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pageEnvelope{
+		Items:      matches,
+		NextCursor: nextCursor,
+		Total:      len(matches),
+	})
+}
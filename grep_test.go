@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGrepContextLines(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id,
+		userEntry(testUUID(2), "line before"),
+		assistantEntry(testUUID(3), "test-model", "the needle sits here"),
+		userEntry(testUUID(4), "line after"),
+		userEntry(testUUID(5), "far away"),
+	)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_grep/"+id+"?q=needle&context=1", nil)
+	w := httptest.NewRecorder()
+	s.handleGrep(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var page struct {
+		Items []grepMatch `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 {
+		t.Fatalf("got %d matches, want 1", len(page.Items))
+	}
+	m := page.Items[0]
+	if !strings.Contains(m.Line.Snippet, "needle") {
+		t.Errorf("match snippet = %q", m.Line.Snippet)
+	}
+	if len(m.Before) != 1 || !strings.Contains(m.Before[0].Snippet, "line before") {
+		t.Errorf("before context = %+v, want the preceding line", m.Before)
+	}
+	if len(m.After) != 1 || !strings.Contains(m.After[0].Snippet, "line after") {
+		t.Errorf("after context = %+v, want the following line", m.After)
+	}
+
+	// An out-of-bounds context window is rejected.
+	r = httptest.NewRequest("GET", "/_grep/"+id+"?q=needle&context=99", nil)
+	w = httptest.NewRecorder()
+	s.handleGrep(w, r)
+	if w.Code != 400 {
+		t.Errorf("context=99 status = %d, want 400", w.Code)
+	}
+}
+
+func TestGrepSurfacesScanError(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	// One line past the scanner's 16MB buffer cap: the scan fails
+	// rather than silently returning partial results.
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "fine"))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	huge := make([]byte, 17*1024*1024)
+	for i := range huge {
+		huge[i] = 'x'
+	}
+	if _, err := f.Write(append(huge, '\n')); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_grep/"+id+"?q=fine", nil)
+	w := httptest.NewRecorder()
+	s.handleGrep(w, r)
+	if w.Code != 500 {
+		t.Fatalf("status = %d, want 500 for an over-long line", w.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gzipped conversations (<uuid>.jsonl.gz) are served with uncompressed
+// offset semantics: Head reports the uncompressed size as the tail
+// offset and Read addresses bytes in the decompressed stream, so
+// clients never have to care which representation is on disk.
+
+// isGzipPath reports whether a conversation file is stored gzipped.
+func isGzipPath(path string) bool {
+	return strings.HasSuffix(path, ".jsonl.gz")
+}
+
+// gzSizeCache caches the uncompressed size of gzipped conversations,
+// keyed by size+mtime like the other derived-state caches. Counting
+// requires a full decompression pass, so it is worth remembering.
+type gzSizeCache struct {
+	mu      sync.Mutex
+	entries map[string]gzSizeEntry
+}
+
+type gzSizeEntry struct {
+	size         int64
+	modTime      time.Time
+	uncompressed int64
+}
+
+// uncompressedSize returns the decompressed byte length of a gzipped
+// conversation file.
+func (s *ClaudeStorage) uncompressedSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	s.gzSizes.mu.Lock()
+	if e, ok := s.gzSizes.entries[path]; ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		s.gzSizes.mu.Unlock()
+		return e.uncompressed, nil
+	}
+	s.gzSizes.mu.Unlock()
+
+	rc, err := openStream(path)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	n, err := io.Copy(io.Discard, rc)
+	if err != nil {
+		return 0, err
+	}
+
+	s.gzSizes.mu.Lock()
+	s.gzSizes.entries[path] = gzSizeEntry{
+		size:         info.Size(),
+		modTime:      info.ModTime(),
+		uncompressed: n,
+	}
+	s.gzSizes.mu.Unlock()
+	return n, nil
+}
+
+// gzipReadCloser closes both the gzip reader and the underlying file.
+type gzipReadCloser struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipReadCloser) Close() error {
+	err := g.Reader.Close()
+	if cerr := g.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// openStream opens a conversation file for sequential reading,
+// transparently decompressing gzipped ones.
+func openStream(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isGzipPath(path) {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipReadCloser{Reader: zr, f: f}, nil
+}
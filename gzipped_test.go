@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// The scan endpoints must see gzipped archives exactly as they see plain
+// JSONL: decompressed, with offsets in uncompressed bytes.
+
+func TestExtractMetadataGzip(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{
+		userEntry(testUUID(1), "how do I test this"),
+		assistantEntry(testUUID(2), "test-model", "like so"),
+	}
+	gzPath := writeGzStream(t, dir, "gz", lines...)
+	plainPath := writeStream(t, dir, "plain", lines...)
+
+	gzMeta, err := extractMetadata(gzPath)
+	if err != nil {
+		t.Fatalf("extractMetadata(gz): %v", err)
+	}
+	plainMeta, err := extractMetadata(plainPath)
+	if err != nil {
+		t.Fatalf("extractMetadata(plain): %v", err)
+	}
+
+	if gzMeta.MessageCount != len(lines) {
+		t.Errorf("gz MessageCount = %d, want %d", gzMeta.MessageCount, len(lines))
+	}
+	if gzMeta.Title == "" {
+		t.Error("gz metadata has no title")
+	}
+	if *gzMeta != *plainMeta {
+		t.Errorf("gz metadata %+v differs from plain %+v", gzMeta, plainMeta)
+	}
+}
+
+func TestDownloadGzipDecompressed(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := userEntry(testUUID(2), "download me")
+	writeGzStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_download/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleDownload(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if got, want := w.Body.String(), line+"\n"; got != want {
+		t.Errorf("body = %q, want decompressed %q", got, want)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, id+".jsonl") {
+		t.Errorf("Content-Disposition = %q, want %s.jsonl", cd, id)
+	}
+}
+
+func TestGrepGzip(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeGzStream(t, projectDir, id,
+		userEntry(testUUID(2), "nothing here"),
+		assistantEntry(testUUID(3), "test-model", "the needle is here"),
+	)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_grep/"+id+"?q=needle", nil)
+	w := httptest.NewRecorder()
+	s.handleGrep(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Items []grepMatch `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Items) != 1 {
+		t.Fatalf("got %d matches, want 1", len(resp.Items))
+	}
+	if !strings.Contains(resp.Items[0].Line.Snippet, "needle") {
+		t.Errorf("snippet = %q, want the needle text", resp.Items[0].Line.Snippet)
+	}
+}
+
+func TestReadSkipGzip(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := []string{
+		userEntry(testUUID(2), "skipped"),
+		assistantEntry(testUUID(3), "test-model", "kept"),
+		userEntry(testUUID(4), "also kept"),
+	}
+	writeGzStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := withReadLimits(context.Background(), &readLimits{skip: 1})
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("got %d messages after skip, want 2", len(result.Messages))
+	}
+	if string(result.Messages[0].Data) != lines[1] {
+		t.Errorf("first message = %s, want %s", result.Messages[0].Data, lines[1])
+	}
+}
+
+func TestExportGzip(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeGzStream(t, projectDir, id,
+		userEntry(testUUID(2), "render this"),
+		assistantEntry(testUUID(3), "test-model", "rendered reply"),
+	)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_export/"+id+"?format=text", nil)
+	w := httptest.NewRecorder()
+	s.handleExport(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "render this") || !strings.Contains(body, "rendered reply") {
+		t.Errorf("rendered export missing conversation text:\n%s", body)
+	}
+}
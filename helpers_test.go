@@ -0,0 +1,105 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Shared fixtures: a minimal Claude directory layout and JSONL
+// conversation lines shaped like the real CLI writes them.
+
+// testUUID returns a deterministic UUID-shaped stream ID; n keeps IDs
+// distinct within a test.
+func testUUID(n int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", n)
+}
+
+// userEntry builds one user-message JSONL line.
+func userEntry(uuid, text string) string {
+	return fmt.Sprintf(`{"type":"user","uuid":%q,"timestamp":%q,"message":{"role":"user","content":%q}}`,
+		uuid, time.Now().UTC().Format(time.RFC3339), text)
+}
+
+// assistantEntry builds one assistant-message JSONL line.
+func assistantEntry(uuid, model, text string) string {
+	return fmt.Sprintf(`{"type":"assistant","uuid":%q,"message":{"role":"assistant","model":%q,"content":[{"type":"text","text":%q}]}}`,
+		uuid, model, text)
+}
+
+// newTestClaudeDir lays out an empty claude directory with one project
+// under projects/ and returns both paths.
+func newTestClaudeDir(t testing.TB) (claudeDir, projectDir string) {
+	t.Helper()
+	claudeDir = t.TempDir()
+	projectDir = filepath.Join(claudeDir, "projects", "-test-project")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return claudeDir, projectDir
+}
+
+// writeStream writes lines as <dir>/<id>.jsonl and returns the path.
+func writeStream(t testing.TB, dir, id string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, id+".jsonl")
+	data := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		data += "\n"
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeGzStream writes lines as <dir>/<id>.jsonl.gz and returns the path.
+func writeGzStream(t testing.TB, dir, id string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, id+".jsonl.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	for _, line := range lines {
+		if _, err := zw.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newTestStorage builds a ClaudeStorage over claudeDir and closes it
+// with the test.
+func newTestStorage(t testing.TB, claudeDir string) *ClaudeStorage {
+	t.Helper()
+	s, err := NewClaudeStorage(claudeDir)
+	if err != nil {
+		t.Fatalf("NewClaudeStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// newSingleFileStorage builds a single-file storage and closes it with
+// the test.
+func newSingleFileStorage(t testing.TB, path string) *ClaudeStorage {
+	t.Helper()
+	s, err := NewSingleFileStorage(path)
+	if err != nil {
+		t.Fatalf("NewSingleFileStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
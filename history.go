@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// enrichedHistoryEntry is one command-history line joined with a preview
+// of its linked conversation, when the session UUID resolves against the
+// index.
+type enrichedHistoryEntry struct {
+	Entry        json.RawMessage `json:"entry"`
+	Conversation *streamMetadata `json:"conversation,omitempty"`
+}
+
+// historyCache memoizes the enriched history response, keyed on the
+// history file's size and mtime. Conversations referenced by history
+// only grow, so their previews stay valid until history itself changes.
+type historyCache struct {
+	mu      sync.Mutex
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+// handleHistoryEnriched returns each history entry joined with a small
+// preview of its linked conversation, turning the command history into a
+// launcher. Entries whose conversation is missing are returned without a
+// preview.
+//
+// With ?follow=1 the connection switches to SSE and pushes each newly
+// appended history entry, already parsed and joined with its session
+// metadata, instead of raw bytes.
+//
+// GET /_history/enriched[?follow=1]
+func (s *ClaudeStorage) handleHistoryEnriched(w http.ResponseWriter, r *http.Request) {
+	if noHistory {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "history disabled by -no-history")
+		return
+	}
+	historyPath := filepath.Join(s.claudeDir, "history.jsonl")
+
+	info, err := os.Stat(historyPath)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "no history file")
+		return
+	}
+
+	if r.URL.Query().Get("follow") == "1" {
+		s.followHistoryEnriched(w, r, historyPath, info.Size())
+		return
+	}
+
+	s.history.mu.Lock()
+	if s.history.data != nil && s.history.size == info.Size() && s.history.modTime.Equal(info.ModTime()) {
+		data := s.history.data
+		s.history.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+	s.history.mu.Unlock()
+
+	f, err := os.Open(historyPath)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open history")
+		return
+	}
+	defer f.Close()
+
+	entries := []enrichedHistoryEntry{}
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !json.Valid(line) {
+			continue
+		}
+
+		var link struct {
+			SessionID string `json:"sessionId"`
+		}
+		json.Unmarshal(line, &link)
+
+		enriched := enrichedHistoryEntry{Entry: append([]byte(nil), line...)}
+		if link.SessionID != "" {
+			if meta, err := s.metadata(link.SessionID); err == nil {
+				enriched.Conversation = meta
+			}
+		}
+		entries = append(entries, enriched)
+	}
+	if err := scanner.Err(); err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "scan history")
+		return
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "encode history")
+		return
+	}
+
+	s.history.mu.Lock()
+	s.history.size = info.Size()
+	s.history.modTime = info.ModTime()
+	s.history.data = data
+	s.history.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// followHistoryEnriched streams newly appended history entries as SSE
+// `entry` events, parsed and enriched like the batch response. Only
+// complete lines are consumed: a partially written entry stays pending
+// until its newline arrives.
+func (s *ClaudeStorage) followHistoryEnriched(w http.ResponseWriter, r *http.Request, historyPath string, offset int64) {
+	ctx := r.Context()
+	ch, err := s.Subscribe(ctx, "_history", offsetFromInt(offset))
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.shutdownCh:
+			io.WriteString(w, "event: shutdown\ndata: {\"reason\":\"server shutting down\"}\n\n")
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+			lines, next, err := completeLinesFrom(historyPath, offset)
+			if err != nil {
+				return
+			}
+			offset = next
+			for _, line := range lines {
+				if !json.Valid(line) {
+					continue
+				}
+				var link struct {
+					SessionID string `json:"sessionId"`
+				}
+				json.Unmarshal(line, &link)
+				enriched := enrichedHistoryEntry{Entry: line}
+				if link.SessionID != "" {
+					if meta, err := s.metadata(link.SessionID); err == nil {
+						enriched.Conversation = meta
+					}
+				}
+				io.WriteString(w, "event: entry\ndata: ")
+				enc.Encode(enriched)
+				io.WriteString(w, "\n")
+			}
+			if len(lines) > 0 && flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// completeLinesFrom returns the newline-terminated lines after offset
+// and the offset just past the last of them. Trailing bytes without a
+// newline — a write in progress — are left for the next call.
+func completeLinesFrom(path string, offset int64) ([][]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	// Truncated and rewritten out from under us; start over.
+	if info.Size() < offset {
+		offset = 0
+	}
+
+	data := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return nil, offset, err
+	}
+
+	var lines [][]byte
+	for {
+		nl := bytes.IndexByte(data, '\n')
+		if nl < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(data[:nl], []byte{'\r'})
+		lines = append(lines, append([]byte(nil), line...))
+		offset += int64(nl + 1)
+		data = data[nl+1:]
+	}
+	return lines, offset, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistoryEnrichedLinksConversations(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id,
+		userEntry(testUUID(2), "linked conversation"),
+		assistantEntry(testUUID(3), "test-model", "reply"),
+	)
+
+	history := `{"display":"run the tests","sessionId":"` + id + `"}` + "\n" +
+		`{"display":"orphaned command","sessionId":"` + testUUID(9) + `"}` + "\n" +
+		`{"display":"no session at all"}` + "\n"
+	if err := os.WriteFile(filepath.Join(claudeDir, "history.jsonl"), []byte(history), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_history/enriched", nil)
+	w := httptest.NewRecorder()
+	s.handleHistoryEnriched(w, r)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var entries []enrichedHistoryEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+
+	// A resolvable session carries its conversation preview.
+	linked := entries[0]
+	if linked.Conversation == nil {
+		t.Fatal("linkable entry has no conversation preview")
+	}
+	if linked.Conversation.ID != id || linked.Conversation.MessageCount != 2 {
+		t.Errorf("preview = %+v, want id %s with 2 messages", linked.Conversation, id)
+	}
+
+	// Unresolvable sessions still return their raw entry, just without
+	// a preview.
+	for i, e := range entries[1:] {
+		if e.Conversation != nil {
+			t.Errorf("entry %d should have no preview, got %+v", i+1, e.Conversation)
+		}
+		if len(e.Entry) == 0 {
+			t.Errorf("entry %d lost its raw history line", i+1)
+		}
+	}
+}
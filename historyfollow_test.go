@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryEnrichedFollowPushesParsedEntries(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "session to link"))
+
+	historyPath := filepath.Join(claudeDir, "history.jsonl")
+	if err := os.WriteFile(historyPath, []byte(`{"display":"old entry"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/_history/enriched?follow=1", nil).WithContext(ctx)
+	w := &lockedRecorder{rec: httptest.NewRecorder()}
+	done := make(chan struct{})
+	go func() {
+		s.handleHistoryEnriched(w, r)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.RLock()
+		n := len(s.subscribers["_history"])
+		s.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("follower never subscribed to _history")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	appendLine(t, historyPath, `{"display":"new command","sessionId":"`+id+`"}`)
+
+	deadline = time.Now().Add(3 * time.Second)
+	for !strings.Contains(w.body(), "event: entry") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no entry event; body:\n%s", w.body())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	body := w.body()
+	// The event carries the parsed entry joined with its session's
+	// metadata, not raw history bytes.
+	if !strings.Contains(body, "new command") {
+		t.Errorf("event missing the appended command:\n%s", body)
+	}
+	if !strings.Contains(body, `"conversation"`) || !strings.Contains(body, id) {
+		t.Errorf("event missing linked session metadata:\n%s", body)
+	}
+	// The pre-existing entry was before the follow offset and is not
+	// replayed.
+	if strings.Contains(body, "old entry") {
+		t.Errorf("follow replayed history from before the subscription:\n%s", body)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+)
+
+// indexCachePath persists the stream index across restarts so startup
+// on a huge projects tree doesn't wait for a full walk. Set by
+// -index-cache; empty disables persistence.
+var indexCachePath string
+
+// indexCacheFile is the on-disk shape of the persisted index.
+type indexCacheFile struct {
+	SavedAt time.Time         `json:"savedAt"`
+	Index   map[string]string `json:"index"` // stream ID -> file path
+}
+
+// loadIndexCache reads a previously saved index. Entries are trusted
+// optimistically: a stale path is pruned lazily by resolveLive on first
+// access, and the background reindex reconciles the rest.
+func loadIndexCache(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cache indexCacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	return cache.Index, nil
+}
+
+// saveIndexCache snapshots the current index to -index-cache, written
+// atomically via temp file + rename. Special streams are skipped; they
+// are re-registered on every start.
+func (s *ClaudeStorage) saveIndexCache() {
+	if indexCachePath == "" {
+		return
+	}
+
+	s.mu.RLock()
+	index := make(map[string]string, len(s.fileIndex))
+	for id, path := range s.fileIndex {
+		if id == "_history" {
+			continue
+		}
+		index[id] = path
+	}
+	s.mu.RUnlock()
+
+	data, err := json.Marshal(indexCacheFile{SavedAt: time.Now(), Index: index})
+	if err != nil {
+		return
+	}
+	tmp := indexCachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.Printf("save index cache: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, indexCachePath); err != nil {
+		os.Remove(tmp)
+		log.Printf("save index cache: %v", err)
+	}
+}
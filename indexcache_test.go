@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestIndexCachePersistsAcrossStartups(t *testing.T) {
+	old := indexCachePath
+	indexCachePath = filepath.Join(t.TempDir(), "index.json")
+	t.Cleanup(func() { indexCachePath = old })
+
+	claudeDir, projectDir := newTestClaudeDir(t)
+	idA := testUUID(1)
+	pathA := writeStream(t, projectDir, idA, userEntry(testUUID(3), "indexed on first run"))
+
+	// The snapshot is written as part of startup indexing.
+	newTestStorage(t, claudeDir)
+	cached, err := loadIndexCache(indexCachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached[idA] != pathA {
+		t.Fatalf("cache maps %s to %q, want %q", idA, cached[idA], pathA)
+	}
+	if _, ok := cached["_history"]; ok {
+		t.Error("special _history stream leaked into the cache")
+	}
+
+	// A stream added while the server was down is absent from the cache;
+	// the background reconcile must pick it up after the cached startup.
+	idB := testUUID(2)
+	writeStream(t, projectDir, idB, userEntry(testUUID(4), "added between runs"))
+
+	second := newTestStorage(t, claudeDir)
+	result, err := second.Read(context.Background(), idA, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("cached stream not served: %v", err)
+	}
+	if len(result.Messages) != 1 {
+		t.Fatalf("read = %+v", result.Messages)
+	}
+	waitForIndex(t, second, idB)
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// inodeOf extracts the inode number from a stat result, used to tell an
+// atomic rename-over-rewrite apart from an append. Returns 0 when the
+// platform info is unavailable.
+func inodeOf(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Ino
+	}
+	return 0
+}
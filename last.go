@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// handleLast returns the most recent assistant message of a conversation
+// as parsed text, together with the offset just past it so a client can
+// subscribe for whatever comes next. The backward walk uses the line
+// index, so only the trailing lines are actually read.
+//
+// GET /_last/{streamID}
+func (s *ClaudeStorage) handleLast(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_last/")
+
+	path, err := s.getPath(streamID)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	// Gzipped archives have no random access, so fall back to a forward
+	// scan that remembers the last assistant message seen.
+	if isGzipPath(path) {
+		s.lastFromScan(w, path)
+		return
+	}
+
+	offsets, err := s.lineOffsets(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "line index")
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "stat stream")
+		return
+	}
+
+	for i := len(offsets) - 1; i >= 0; i-- {
+		start := offsets[i]
+		end := info.Size()
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+		line := make([]byte, end-start)
+		if _, err := f.ReadAt(line, start); err != nil {
+			apiError(w, http.StatusInternalServerError, codeInternal, "read stream")
+			return
+		}
+		line = []byte(strings.TrimRight(string(line), "\r\n"))
+
+		var entry lastEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		if entry.Type != "assistant" && entry.Message.Role != "assistant" {
+			continue
+		}
+
+		writeLast(w, entry, end)
+		return
+	}
+
+	apiError(w, http.StatusNotFound, codeMessageNotFound, "no assistant message in stream")
+}
+
+// lastEntry is the subset of a JSONL line handleLast cares about.
+type lastEntry struct {
+	Type    string `json:"type"`
+	UUID    string `json:"uuid"`
+	Message struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// writeLast encodes the /_last response for an assistant entry ending at
+// the given offset.
+func writeLast(w http.ResponseWriter, entry lastEntry, end int64) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		UUID   string `json:"uuid,omitempty"`
+		Text   string `json:"text"`
+		Offset string `json:"offset"`
+	}{entry.UUID, contentText(entry.Message.Content), string(offsetFromInt(end))})
+}
+
+// lastFromScan serves /_last for streams without random access by
+// scanning forward through openStream. Offsets count uncompressed bytes,
+// matching Read.
+func (s *ClaudeStorage) lastFromScan(w http.ResponseWriter, path string) {
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	var (
+		last    lastEntry
+		lastEnd int64
+		found   bool
+		offset  int64
+	)
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+	scanner.Split(scanLinesKeepCR)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		offset += int64(len(line) + 1)
+
+		var entry lastEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		if entry.Type != "assistant" && entry.Message.Role != "assistant" {
+			continue
+		}
+		last, lastEnd, found = entry, offset, true
+	}
+	if err := scanner.Err(); err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "scan stream")
+		return
+	}
+	if !found {
+		apiError(w, http.StatusNotFound, codeMessageNotFound, "no assistant message in stream")
+		return
+	}
+	writeLast(w, last, lastEnd)
+}
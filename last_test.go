@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestLastAssistantMessage(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := []string{
+		userEntry(testUUID(2), "question"),
+		assistantEntry(testUUID(3), "test-model", "first answer"),
+		userEntry(testUUID(4), "follow-up"),
+		assistantEntry(testUUID(5), "test-model", "final answer"),
+		userEntry(testUUID(6), "thanks"),
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_last/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleLast(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		UUID   string `json:"uuid"`
+		Text   string `json:"text"`
+		Offset string `json:"offset"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.UUID != testUUID(5) || resp.Text != "final answer" {
+		t.Errorf("got %s %q, want the last assistant message", resp.UUID, resp.Text)
+	}
+
+	// The offset points just past the assistant line so a client can
+	// subscribe for what follows it.
+	var end int64
+	for _, l := range lines[:4] {
+		end += int64(len(l) + 1)
+	}
+	if got := offsetToInt(durablestream.Offset(resp.Offset)); got != end {
+		t.Errorf("offset = %d, want %d", got, end)
+	}
+}
+
+func TestLastNoAssistant(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "just me"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_last/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleLast(w, r)
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+	if code, _ := decodeAPIError(t, w.Body.Bytes()); code != codeMessageNotFound {
+		t.Errorf("code = %s, want %s", code, codeMessageNotFound)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// lineIndexCache caches the byte offsets of line starts per file, so
+// message-count arithmetic (skip, backward scans) doesn't require
+// rescanning from the top of large conversations. Conversation files are
+// append-only, so an existing index is extended rather than rebuilt when
+// the file grows.
+type lineIndexCache struct {
+	mu      sync.Mutex
+	entries map[string]*lineIndexEntry
+}
+
+type lineIndexEntry struct {
+	size    int64
+	modTime time.Time
+	offsets []int64 // offsets[i] = byte offset of the start of line i
+
+	// With -compress-line-index the offsets are held as varint-encoded
+	// deltas instead (offsets is nil), cutting resident memory roughly
+	// 8x for large conversations at the cost of decoding on lookup.
+	packed []byte
+	count  int
+}
+
+// compressLineIndex stores cached line indexes delta-compressed.
+// Set by -compress-line-index.
+var compressLineIndex bool
+
+// encodeOffsets packs a monotonically increasing offset array as varint
+// deltas.
+func encodeOffsets(offsets []int64) []byte {
+	buf := make([]byte, 0, len(offsets))
+	var tmp [binary.MaxVarintLen64]byte
+	prev := int64(0)
+	for _, off := range offsets {
+		n := binary.PutUvarint(tmp[:], uint64(off-prev))
+		buf = append(buf, tmp[:n]...)
+		prev = off
+	}
+	return buf
+}
+
+// decodeOffsets reverses encodeOffsets. count is the number of offsets
+// packed, known from encoding time.
+func decodeOffsets(packed []byte, count int) []int64 {
+	offsets := make([]int64, 0, count)
+	prev := int64(0)
+	for len(packed) > 0 {
+		d, n := binary.Uvarint(packed)
+		if n <= 0 {
+			break
+		}
+		packed = packed[n:]
+		prev += int64(d)
+		offsets = append(offsets, prev)
+	}
+	return offsets
+}
+
+// cachedOffsets materializes an entry's offset array regardless of
+// representation.
+func (e *lineIndexEntry) cachedOffsets() []int64 {
+	if e.packed != nil {
+		return decodeOffsets(e.packed, e.count)
+	}
+	return e.offsets
+}
+
+// lineOffsets returns the byte offsets of all line starts in the file.
+// The returned slice must not be mutated.
+func (s *ClaudeStorage) lineOffsets(path string) ([]int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lines.mu.Lock()
+	defer s.lines.mu.Unlock()
+
+	e := s.lines.entries[path]
+	if e != nil && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e.cachedOffsets(), nil
+	}
+
+	var (
+		offsets     []int64
+		start       int64
+		r           io.ReadCloser
+		atLineStart = true
+	)
+	if isGzipPath(path) {
+		// Offsets index the uncompressed stream, matching what Read
+		// serves. Gzip output can't be extended in place, so a changed
+		// archive is rescanned from the top.
+		r, err = openStream(path)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if e != nil && info.Size() > e.size {
+			// Append-only growth: extend from the previous end.
+			offsets = e.cachedOffsets()
+			start = e.size
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		r = f
+
+		if start > 0 {
+			// Whether the next byte starts a line depends on whether the
+			// previously indexed content ended with a newline.
+			var prev [1]byte
+			if _, err := f.ReadAt(prev[:], start-1); err != nil {
+				f.Close()
+				return nil, err
+			}
+			atLineStart = prev[0] == '\n'
+		}
+
+		if _, err := f.Seek(start, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	defer r.Close()
+
+	br := bufio.NewReaderSize(r, 64*1024)
+	pos := start
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			break
+		}
+		if atLineStart {
+			offsets = append(offsets, pos)
+			atLineStart = false
+		}
+		pos++
+		if b == '\n' {
+			atLineStart = true
+		}
+	}
+
+	entry := &lineIndexEntry{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+	if compressLineIndex {
+		entry.packed = encodeOffsets(offsets)
+		entry.count = len(offsets)
+	} else {
+		entry.offsets = offsets
+	}
+	s.lines.entries[path] = entry
+	return offsets, nil
+}
+
+// tailChunkSize is the read granularity of the backward scan in
+// readLastLines.
+const tailChunkSize = 64 * 1024
+
+// readLastLines returns the last n lines of a JSONL file as stored
+// messages, reading fixed-size chunks backward from the end so large
+// conversations don't pay a full forward scan. Offsets follow the Read
+// convention: each message's offset is the position just past its line,
+// so a partial trailing line (file mid-write) is still returned with its
+// offset at EOF.
+func readLastLines(path string, n int) ([]durablestream.StoredMessage, error) {
+	if isGzipPath(path) {
+		return readLastLinesStream(path, n)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	// Grow the buffer backward a chunk at a time until it holds more
+	// newlines than lines wanted: the extra one guards against the
+	// buffer starting mid-line.
+	var (
+		buf   []byte
+		start = size
+	)
+	for start > 0 {
+		step := int64(tailChunkSize)
+		if step > start {
+			step = start
+		}
+		chunk := make([]byte, step)
+		if _, err := f.ReadAt(chunk, start-step); err != nil {
+			return nil, err
+		}
+		buf = append(chunk, buf...)
+		start -= step
+		if bytes.Count(buf, []byte{'\n'}) > n {
+			break
+		}
+	}
+
+	// Unless the buffer reaches the top of the file its first line may be
+	// partial; skip past its newline.
+	pos := start
+	if start > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return nil, nil
+		}
+		buf = buf[i+1:]
+		pos += int64(i + 1)
+	}
+
+	var messages []durablestream.StoredMessage
+	for len(buf) > 0 {
+		line, adv := buf, len(buf)
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			line, adv = buf[:i], i+1
+		}
+		pos += int64(adv)
+		buf = buf[adv:]
+
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		data := make([]byte, len(line))
+		copy(data, line)
+		messages = append(messages, durablestream.StoredMessage{
+			Data:   data,
+			Offset: offsetFromInt(pos),
+		})
+	}
+	if len(messages) > n {
+		messages = messages[len(messages)-n:]
+	}
+	return messages, nil
+}
+
+// readLastLinesStream is the gzip fallback for readLastLines: without
+// random access the whole stream is decompressed forward, keeping only
+// the trailing n lines. Offsets count uncompressed bytes, matching Read.
+func readLastLinesStream(path string, n int) ([]durablestream.StoredMessage, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	rc, err := openStream(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReaderSize(rc, tailChunkSize)
+	var (
+		messages []durablestream.StoredMessage
+		pos      int64
+	)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			pos += int64(len(line))
+			data := bytes.TrimSuffix(line, []byte{'\n'})
+			data = bytes.TrimSuffix(data, []byte{'\r'})
+			messages = append(messages, durablestream.StoredMessage{
+				Data:   append([]byte(nil), data...),
+				Offset: offsetFromInt(pos),
+			})
+			if len(messages) > n {
+				messages = messages[1:]
+			}
+		}
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// skipLines returns the offset that is n line starts past the line
+// containing (or starting at) offset.
+func skipLines(offsets []int64, offset int64, n int) int64 {
+	idx := sort.Search(len(offsets), func(i int) bool { return offsets[i] >= offset })
+	idx += n
+	if idx >= len(offsets) {
+		// Past the end: land at EOF by returning a huge offset; the
+		// caller's Seek+scan yields no messages, which is correct.
+		if len(offsets) == 0 {
+			return offset
+		}
+		return int64(1<<62 - 1)
+	}
+	return offsets[idx]
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+)
+
+// benchOffsets builds a realistic monotonically increasing offset array:
+// many lines with slightly varying lengths.
+func benchOffsets(n int) []int64 {
+	offsets := make([]int64, n)
+	var off int64
+	for i := range offsets {
+		off += int64(120 + i%80)
+		offsets[i] = off
+	}
+	return offsets
+}
+
+// The point of -compress-line-index: packed deltas are a fraction of the
+// raw 8 bytes per offset. Run with -benchmem to see the allocation gap.
+func BenchmarkLineIndexRaw(b *testing.B) {
+	offsets := benchOffsets(100_000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dup := make([]int64, len(offsets))
+		copy(dup, offsets)
+		_ = dup
+	}
+	b.ReportMetric(float64(len(offsets)*8), "raw-bytes")
+}
+
+func BenchmarkLineIndexPacked(b *testing.B) {
+	offsets := benchOffsets(100_000)
+	b.ReportAllocs()
+	var packedLen int
+	for i := 0; i < b.N; i++ {
+		packed := encodeOffsets(offsets)
+		packedLen = len(packed)
+	}
+	b.ReportMetric(float64(packedLen), "packed-bytes")
+}
+
+func BenchmarkLineIndexDecode(b *testing.B) {
+	offsets := benchOffsets(100_000)
+	packed := encodeOffsets(offsets)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = decodeOffsets(packed, len(offsets))
+	}
+}
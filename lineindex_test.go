@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Line index offsets must count uncompressed bytes for gzipped archives,
+// so they line up with the offsets Read serves.
+
+func TestLineOffsetsGzip(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	lines := []string{
+		userEntry(testUUID(1), "first"),
+		assistantEntry(testUUID(2), "test-model", "second"),
+		userEntry(testUUID(3), "third"),
+	}
+	gzPath := writeGzStream(t, projectDir, "gz", lines...)
+	plainPath := writeStream(t, projectDir, "plain", lines...)
+	s := newTestStorage(t, claudeDir)
+
+	gzOffsets, err := s.lineOffsets(gzPath)
+	if err != nil {
+		t.Fatalf("lineOffsets(gz): %v", err)
+	}
+	plainOffsets, err := s.lineOffsets(plainPath)
+	if err != nil {
+		t.Fatalf("lineOffsets(plain): %v", err)
+	}
+	if fmt.Sprint(gzOffsets) != fmt.Sprint(plainOffsets) {
+		t.Errorf("gz offsets %v differ from plain %v", gzOffsets, plainOffsets)
+	}
+}
+
+func TestMessageGzip(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	first := userEntry(testUUID(2), "first")
+	second := assistantEntry(testUUID(3), "test-model", "second")
+	writeGzStream(t, projectDir, id, first, second)
+	s := newTestStorage(t, claudeDir)
+
+	// The second line starts just past the first in uncompressed bytes.
+	off := len(first) + 1
+	r := httptest.NewRequest("GET", fmt.Sprintf("/_message/%s?offset=%d", id, off), nil)
+	w := httptest.NewRecorder()
+	s.handleMessage(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Message json.RawMessage `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Message) != second {
+		t.Errorf("message = %s, want %s", resp.Message, second)
+	}
+}
+
+func TestLastGzip(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeGzStream(t, projectDir, id,
+		userEntry(testUUID(2), "question"),
+		assistantEntry(testUUID(3), "test-model", "the final answer"),
+	)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_last/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleLast(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Text   string `json:"text"`
+		Offset string `json:"offset"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "the final answer" {
+		t.Errorf("text = %q", resp.Text)
+	}
+	if strings.TrimLeft(resp.Offset, "0") == "" {
+		t.Errorf("offset = %q, want past the last line", resp.Offset)
+	}
+}
+
+func TestOffsetsRoundTrip(t *testing.T) {
+	offsets := []int64{12, 150, 151, 4096, 1 << 40}
+	packed := encodeOffsets(offsets)
+	if len(packed) >= len(offsets)*8 {
+		t.Errorf("packed %d bytes, no smaller than raw %d", len(packed), len(offsets)*8)
+	}
+	got := decodeOffsets(packed, len(offsets))
+	if fmt.Sprint(got) != fmt.Sprint(offsets) {
+		t.Errorf("round trip = %v, want %v", got, offsets)
+	}
+	if out := decodeOffsets(encodeOffsets(nil), 0); len(out) != 0 {
+		t.Errorf("empty round trip = %v", out)
+	}
+}
+
+func TestCompressedIndexMatchesUncompressed(t *testing.T) {
+	old := compressLineIndex
+	t.Cleanup(func() { compressLineIndex = old })
+
+	lines := []string{
+		userEntry(testUUID(1), "one"),
+		assistantEntry(testUUID(2), "test-model", "two"),
+		userEntry(testUUID(3), "three"),
+	}
+
+	var results [][]int64
+	for _, compress := range []bool{false, true} {
+		compressLineIndex = compress
+		claudeDir, projectDir := newTestClaudeDir(t)
+		path := writeStream(t, projectDir, testUUID(1), lines...)
+		s := newTestStorage(t, claudeDir)
+		offsets, err := s.lineOffsets(path)
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		results = append(results, offsets)
+	}
+	if fmt.Sprint(results[0]) != fmt.Sprint(results[1]) {
+		t.Errorf("compressed index %v differs from uncompressed %v", results[1], results[0])
+	}
+}
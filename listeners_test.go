@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeOnMultipleListeners(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "reachable both ways"))
+	s := newTestStorage(t, claudeDir)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_healthz", s.handleHealthz)
+	mux.HandleFunc("/_tail/", s.handleTail)
+
+	// One server per listener, sharing the handler, the way main wires
+	// -addr: a TCP port for remote clients and a Unix socket for local
+	// tools.
+	tcpLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sockPath := filepath.Join(t.TempDir(), "claude2stream.sock")
+	unixLn, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ln := range []net.Listener{tcpLn, unixLn} {
+		srv := &http.Server{Handler: mux}
+		go srv.Serve(ln)
+		t.Cleanup(func() { srv.Close() })
+	}
+
+	tcpClient := &http.Client{Timeout: 2 * time.Second}
+	unixClient := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sockPath)
+			},
+		},
+	}
+
+	for name, c := range map[string]*http.Client{
+		"tcp":  tcpClient,
+		"unix": unixClient,
+	} {
+		url := "http://" + tcpLn.Addr().String() + "/_tail/" + id
+		if name == "unix" {
+			url = "http://unix/_tail/" + id
+		}
+		resp, err := c.Get(url)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: read body: %v", name, err)
+		}
+		if resp.StatusCode != 200 || !strings.Contains(string(body), "reachable both ways") {
+			t.Errorf("%s: status %d, body %s", name, resp.StatusCode, body)
+		}
+	}
+}
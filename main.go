@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"encoding/base64"
 	"flag"
+	"fmt"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ahimsalabs/durable-streams-go/durablestream"
 )
@@ -16,64 +25,685 @@ import (
 //go:embed webui/dist/*
 var webUI embed.FS
 
+// uuidRe matches conversation stream IDs, which are always UUIDs.
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 func main() {
-	addr := flag.String("addr", ":8214", "listen address")
+	var addrs stringList
+	flag.Var(&addrs, "addr", "listen address, repeatable; unix: prefix or a path serves a Unix socket (default :8214)")
 	claudeDir := flag.String("dir", "", "claude directory (default: ~/.claude)")
+	singleFile := flag.String("file", "", "serve exactly this JSONL file instead of a claude directory")
+	extraDirs := flag.String("dirs", "", "additional claude roots as alias=path pairs, comma-separated")
+	uiDir := flag.String("ui-dir", "", "serve UI from this directory instead of the embedded build")
+	noUI := flag.Bool("no-ui", false, "serve the API only, without the web UI")
+	adminToken := flag.String("admin-token", "", "bearer token for admin endpoints (disabled when empty)")
+	noSubscribe := flag.String("no-subscribe", "", "glob of stream IDs that can be read but not subscribed to")
 	dev := flag.Bool("dev", false, "enable CORS for development")
+	check := flag.Bool("check", false, "validate the configuration, print a summary and exit")
+	auditLog := flag.String("audit-log", "", "append an audit entry for every conversation read to this file")
+	var extraFiles stringList
+	flag.Var(&extraFiles, "extra-file", "serve a JSONL file outside the projects tree as id=/abs/path (repeatable)")
+	flag.DurationVar(&scanTimeout, "scan-timeout", scanTimeout, "deadline for whole-file scan endpoints (0 disables)")
+	flag.BoolVar(&useMmap, "mmap", false, "read conversations via mmap where supported")
+	defaultReadBytes := flag.Int("default-read-bytes", 1<<20, "read page size when the client does not request one")
+	arrayReadBytes := flag.Int("array-read-bytes", 256<<10, "default page size for JSON array responses (non-live reads)")
+	flag.IntVar(&maxReadLimitBytes, "max-read-bytes", maxReadLimitBytes, "hard ceiling for client-requested read sizes")
+	flag.IntVar(&subscribeBuffer, "subscribe-buffer", subscribeBuffer, "notification channel depth per subscriber")
+	flag.Int64Var(&maxStreamBytes, "max-stream-bytes", 0, "exclude streams larger than this from serving (0 = no limit)")
+	flag.IntVar(&globalReadRate, "read-rate", 0, "global read throttle in bytes/sec (0 disables)")
+	flag.IntVar(&streamReadRate, "stream-read-rate", 0, "per-stream read throttle in bytes/sec (0 disables)")
+	redactFile := flag.String("redact-patterns", "", "file of regexes (one per line) masked in redacted output")
+	flag.IntVar(&corsMaxAge, "cors-max-age", corsMaxAge, "seconds browsers may cache CORS preflight responses")
+	bookmarksFile := flag.String("bookmarks-file", "", "JSON file persisting per-stream last-read offsets")
+	annotationsFile := flag.String("annotations-file", "", "JSON file persisting per-stream titles and pins")
+	flag.BoolVar(&compressLineIndex, "compress-line-index", false, "store cached line indexes delta-compressed to save memory")
+	flag.BoolVar(&noHistory, "no-history", false, "do not serve the _history command-history stream")
+	flag.StringVar(&indexCachePath, "index-cache", "", "persist the stream index here to speed up startup")
 	flag.Parse()
 
-	dir := *claudeDir
-	if dir == "" {
-		home, err := os.UserHomeDir()
+	var (
+		storage       *ClaudeStorage
+		err           error
+		dir           string
+		fixedStreamID string
+	)
+	if *singleFile != "" {
+		// Single-file mode: serve one conversation, no projects tree,
+		// no _history.
+		storage, err = NewSingleFileStorage(*singleFile)
+		if err != nil {
+			log.Fatalf("create storage: %v", err)
+		}
+		dir = *singleFile
+		fixedStreamID = strings.TrimSuffix(filepath.Base(*singleFile), ".jsonl")
+	} else {
+		dir = *claudeDir
+		if dir == "" {
+			dir, err = detectClaudeDir()
+			if err != nil {
+				log.Fatalf("detect claude dir: %v", err)
+			}
+			log.Printf("Using Claude directory: %s", dir)
+		}
+		storage, err = NewClaudeStorage(dir)
 		if err != nil {
-			log.Fatalf("get home dir: %v", err)
+			log.Fatalf("create storage: %v", err)
 		}
-		dir = filepath.Join(home, ".claude")
 	}
 
-	storage, err := NewClaudeStorage(dir)
-	if err != nil {
-		log.Fatalf("create storage: %v", err)
+	storage.noSubscribe = *noSubscribe
+
+	extraIDs := map[string]bool{}
+	for _, spec := range extraFiles {
+		id, path, ok := strings.Cut(spec, "=")
+		if !ok || id == "" || strings.Contains(id, "/") || strings.HasPrefix(id, "_") {
+			log.Fatalf("parse -extra-file %q: want id=/abs/path with a plain id", spec)
+		}
+		if err := storage.registerExtraFile(id, path); err != nil {
+			log.Fatalf("register -extra-file %q: %v", spec, err)
+		}
+		extraIDs[id] = true
+	}
+
+	if *redactFile != "" {
+		if err := loadRedactPatterns(*redactFile); err != nil {
+			log.Fatalf("redact patterns: %v", err)
+		}
 	}
-	defer storage.Close()
 
-	streamHandler := durablestream.NewHandler(storage, nil)
+	if *bookmarksFile != "" {
+		if err := ensureParentDir(*bookmarksFile); err != nil {
+			log.Fatalf("bookmarks file: %v", err)
+		}
+		storage.bookmarks, err = newBookmarkStore(*bookmarksFile)
+		if err != nil {
+			log.Fatalf("bookmarks file: %v", err)
+		}
+	}
+
+	if *annotationsFile != "" {
+		if err := ensureParentDir(*annotationsFile); err != nil {
+			log.Fatalf("annotations file: %v", err)
+		}
+		storage.annotations, err = newAnnotationStore(*annotationsFile)
+		if err != nil {
+			log.Fatalf("annotations file: %v", err)
+		}
+	}
+
+	if *auditLog != "" {
+		audit, err := newAuditLogger(*auditLog)
+		if err != nil {
+			log.Fatalf("audit log: %v", err)
+		}
+		storage.audit = audit
+	}
+
+	// -check validates the directory and reports what would be served,
+	// then exits; useful in scripts before going live.
+	if *check {
+		printCheckSummary(storage, dir)
+		storage.Close()
+		return
+	}
+
+	// Additional roots are addressed as <alias>/<uuid>, which removes
+	// any ambiguity when the same UUID exists under two roots.
+	var store durablestream.Storage = storage
+	roots := map[string]*ClaudeStorage{}
+	if *extraDirs != "" {
+		roots, err = parseRoots(*extraDirs)
+		if err != nil {
+			log.Fatalf("parse -dirs: %v", err)
+		}
+		for _, root := range roots {
+			root.noSubscribe = *noSubscribe
+			root.audit = storage.audit
+		}
+		multi := &multiStorage{def: storage, roots: roots}
+		defer multi.Close()
+		store = multi
+	} else {
+		defer storage.Close()
+	}
+
+	// The default page size tunes the typical read; the max caps what a
+	// client may explicitly ask for, so the default must fit under it.
+	if *defaultReadBytes <= 0 || *defaultReadBytes > maxReadLimitBytes {
+		log.Fatalf("-default-read-bytes must be between 1 and -max-read-bytes (%d)", maxReadLimitBytes)
+	}
+	if *arrayReadBytes <= 0 || *arrayReadBytes > maxReadLimitBytes {
+		log.Fatalf("-array-read-bytes must be between 1 and -max-read-bytes (%d)", maxReadLimitBytes)
+	}
+	if subscribeBuffer < 1 {
+		log.Fatalf("-subscribe-buffer must be at least 1")
+	}
+	streamHandler := durablestream.NewHandler(store, &durablestream.HandlerConfig{
+		ChunkSize: *defaultReadBytes,
+	})
 
 	// Build the main handler
 	mux := http.NewServeMux()
 
-	// Serve embedded UI at /ui/
-	uiFS, err := fs.Sub(webUI, "webui/dist")
-	if err != nil {
-		log.Fatalf("embed ui: %v", err)
+	if err := setupUI(mux, *noUI, *uiDir); err != nil {
+		log.Fatalf("ui: %v", err)
+	}
+
+	// Custom endpoints (see api.go). Each carries a cache policy: live
+	// streams must never be cached, listings only briefly, and exports —
+	// revalidated by ETag — can be cached for a while.
+	mux.HandleFunc("/_stream/", cachePolicy(cacheLive, storage.handleStreamJSON))
+	mux.HandleFunc("/_metadata", cachePolicy(cacheBrief, storage.handleMetadataBatch))
+	mux.HandleFunc("/_metadata/feed", cachePolicy(cacheLive, storage.handleMetadataFeed))
+	mux.HandleFunc("/_info", cachePolicy(cacheBrief, storage.handleInfo))
+	mux.HandleFunc("/_range/", cachePolicy(cacheBrief, storage.handleRange))
+	mux.HandleFunc("/_chain/", cachePolicy(cacheBrief, storage.handleChain))
+	mux.HandleFunc("/_cache/flush", requireAdmin(*adminToken, storage.handleCacheFlush))
+	mux.HandleFunc("/_download/", cachePolicy(cacheExport, storage.handleDownload))
+	mux.HandleFunc("/_history/enriched", cachePolicy(cacheBrief, storage.handleHistoryEnriched))
+	mux.HandleFunc("/_tail/", cachePolicy(cacheLive, storage.handleTail))
+	mux.HandleFunc("/_streams", cachePolicy(cacheBrief, storage.handleListStreams))
+	mux.HandleFunc("/_compare", cachePolicy(cacheBrief, storage.handleCompare))
+	mux.HandleFunc("/_stats/", cachePolicy(cacheBrief, storage.handleStats))
+	mux.HandleFunc("/_last/", cachePolicy(cacheBrief, storage.handleLast))
+	mux.HandleFunc("/_concat", cachePolicy(cacheLive, storage.handleConcat))
+	mux.HandleFunc("/p/", storage.handleProject)
+	mux.HandleFunc("/_outline/", cachePolicy(cacheBrief, storage.handleOutline))
+	mux.HandleFunc("/_replay/", cachePolicy(cacheLive, storage.handleReplay))
+	mux.HandleFunc("/_healthz", cachePolicy(cacheLive, storage.handleHealthz))
+	mux.HandleFunc("/_filtered/", cachePolicy(cacheBrief, storage.handleFiltered))
+	mux.HandleFunc("/_resume/", cachePolicy(cacheBrief, storage.handleResume))
+	mux.HandleFunc("/_export/", cachePolicy(cacheExport, storage.handleExport))
+	mux.HandleFunc("/_changed", cachePolicy(cacheLive, storage.handleChanged))
+	mux.HandleFunc("/_current", cachePolicy(cacheLive, storage.handleCurrent))
+	mux.HandleFunc("/_bookmark/", cachePolicy(cacheLive, storage.handleBookmark))
+	mux.HandleFunc("/_annotate/", cachePolicy(cacheLive, storage.handleAnnotate))
+	mux.HandleFunc("/_grep/", cachePolicy(cacheBrief, storage.handleGrep))
+	mux.HandleFunc("/_message/", cachePolicy(cacheBrief, storage.handleMessage))
+	mux.HandleFunc("/_bundle", cachePolicy(cacheExport, storage.handleBundle))
+	mux.HandleFunc("/_projects/tree", cachePolicy(cacheBrief, storage.handleProjectsTree))
+	if *dev {
+		mux.HandleFunc("/_debug/events", cachePolicy(cacheLive, storage.handleDebugEvents))
+		mux.HandleFunc("/_debug/index", cachePolicy(cacheLive, storage.handleDebugIndex))
+	} else {
+		mux.HandleFunc("/_debug/events", requireAdmin(*adminToken, cachePolicy(cacheLive, storage.handleDebugEvents)))
+		mux.HandleFunc("/_debug/index", requireAdmin(*adminToken, cachePolicy(cacheLive, storage.handleDebugIndex)))
 	}
-	mux.Handle("/ui/", http.StripPrefix("/ui/", spaHandler(http.FileServer(http.FS(uiFS)))))
 
-	// Redirect root to UI
+	// Redirect root to UI (or identify the API when the UI is disabled)
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
+			if *noUI {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, "{\"service\":\"claude2stream\",\"version\":%q,\"streams\":\"/_streams\"}\n", apiVersion)
+				return
+			}
 			http.Redirect(w, r, "/ui/", http.StatusFound)
 			return
 		}
-		// All other paths go to stream handler
+		// Only UUID-shaped IDs (optionally alias-prefixed) and the
+		// special streams are worth handing to the stream handler;
+		// anything else gets a clean 404 instead of a confusing
+		// stream error.
+		streamID := strings.TrimPrefix(r.URL.Path, "/")
+		if alias, rest, ok := strings.Cut(streamID, "/"); ok {
+			if _, known := roots[alias]; known {
+				streamID = rest
+			}
+		}
+		if streamID != "_history" && streamID != fixedStreamID && !extraIDs[streamID] && !uuidRe.MatchString(streamID) {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Stream paths go to the stream handler. Long-poll and SSE
+		// responses flush as soon as a Subscribe notification lands, so
+		// tell intermediate proxies (e.g. nginx) not to buffer them.
+		w.Header().Set("X-Accel-Buffering", "no")
+
+		// Every Claude stream is read-only; advertise that so clients
+		// know not to attempt appends.
+		w.Header().Set("Stream-Read-Only", "true")
+		rl, err := parseReadLimits(r, *arrayReadBytes)
+		if err != nil {
+			apiError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		if rl.bytes > 0 || rl.messages > 0 || rl.skip > 0 {
+			r = r.WithContext(withReadLimits(r.Context(), rl))
+		}
+		if v := r.URL.Query().Get("fields"); v != "" {
+			r = r.WithContext(withProjection(r.Context(), strings.Split(v, ",")))
+		}
+		if r.URL.Query().Get("view") == "compact" {
+			r = r.WithContext(withCompactView(r.Context()))
+		}
+		if r.URL.Query().Get("merge") == "1" {
+			r = r.WithContext(withMergedRead(r.Context()))
+		}
+		if r.URL.Query().Get("normalize") == "1" {
+			r = r.WithContext(withNormalize(r.Context()))
+		}
+		if r.URL.Query().Get("force") == "1" {
+			r = r.WithContext(withForceRead(r.Context()))
+		}
+		if r.URL.Query().Get("annotate") == "1" {
+			r = r.WithContext(withAnnotate(r.Context()))
+		}
+		if r.URL.Query().Get("skeleton") == "1" {
+			r = r.WithContext(withSkeleton(r.Context()))
+		}
+		if r.URL.Query().Get("dedup") == "1" {
+			r = r.WithContext(withDedup(r.Context()))
+		}
+		if v := r.URL.Query().Get("types"); v != "" {
+			types := make(map[string]bool)
+			for _, t := range strings.Split(v, ",") {
+				types[t] = true
+			}
+			r = r.WithContext(withSubscribeTypes(r.Context(), types))
+		}
+		if v := r.URL.Query().Get("coalesce"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				apiError(w, http.StatusBadRequest, codeBadRequest, "invalid coalesce window (want a duration like 200ms)")
+				return
+			}
+			if d > maxCoalesceWindow {
+				d = maxCoalesceWindow
+			}
+			r = r.WithContext(withCoalesce(r.Context(), d))
+		}
+		// Identity rides along for audit logging of the read.
+		r = r.WithContext(withIdentity(r.Context(), requestIdentity(r)))
+		// Read only learns mid-request that the client's offset is past
+		// the tail; the wrapper turns that into a response header.
+		var resetRequired bool
+		r = r.WithContext(withResetSignal(r.Context(), &resetRequired))
+		w = &resetHeaderWriter{ResponseWriter: w, flag: &resetRequired}
+		// The returned bytes' digest rides back as Stream-Digest; a
+		// client resuming a download sends it back as Stream-If-Digest
+		// to assert the content at its offset hasn't shifted.
+		digest := &readDigest{}
+		r = r.WithContext(withReadDigest(r.Context(), digest))
+		w = &digestHeaderWriter{ResponseWriter: w, digest: digest, ifDigest: r.Header.Get("Stream-If-Digest")}
+		// Clients that named their offset get the served byte range and
+		// total size back, so resumable downloads can verify coverage.
+		if r.Header.Get("Stream-Offset") != "" || r.URL.Query().Get("offset") != "" {
+			rng := &readRange{}
+			r = r.WithContext(withReadRange(r.Context(), rng))
+			w = &rangeHeaderWriter{ResponseWriter: w, rng: rng}
+		}
+		if since := r.URL.Query().Get("since"); since != "" {
+			t, err := time.Parse(time.RFC3339, since)
+			if err != nil {
+				apiError(w, http.StatusBadRequest, codeBadRequest, "invalid since timestamp (want RFC3339)")
+				return
+			}
+			filter := &readFilter{
+				since:          t,
+				includeUntimed: r.URL.Query().Get("untimed") != "skip",
+			}
+			r = r.WithContext(withReadFilter(r.Context(), filter))
+		}
 		streamHandler.ServeHTTP(w, r)
 	})
 
-	var handler http.Handler = mux
+	handler := requestIDMiddleware(apiVersionMiddleware(mux))
 	if *dev {
-		handler = corsMiddleware(mux)
+		handler = corsMiddleware(handler)
 		log.Printf("CORS enabled for development")
 	}
 
-	log.Printf("Claude streams server listening on %s", *addr)
+	if len(addrs) == 0 {
+		addrs = stringList{":8214"}
+	}
+
+	// One http.Server per address, all sharing the handler and shut down
+	// together: local tools can use a Unix socket while remote clients
+	// use TCP.
+	servers := make([]*http.Server, 0, len(addrs))
+	errc := make(chan error, len(addrs))
+	// Request contexts derive from baseCtx so stragglers that ignore the
+	// shutdown broadcast can still be cancelled as a last resort.
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+	for _, a := range addrs {
+		network, address := "tcp", a
+		if rest, ok := strings.CutPrefix(a, "unix:"); ok {
+			network, address = "unix", rest
+		} else if strings.Contains(a, "/") {
+			network = "unix"
+		}
+		if network == "unix" {
+			// A stale socket from a previous run would fail the bind.
+			os.Remove(address)
+		}
+		ln, err := net.Listen(network, address)
+		if err != nil {
+			log.Fatalf("listen %s: %v", a, err)
+		}
+		srv := &http.Server{
+			Handler:     handler,
+			BaseContext: func(net.Listener) context.Context { return baseCtx },
+		}
+		servers = append(servers, srv)
+		log.Printf("Claude streams server listening on %s", a)
+		go func() {
+			if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+				errc <- err
+			}
+		}()
+	}
 	log.Printf("Watching: %s", dir)
-	log.Printf("UI: http://localhost%s/ui/", *addr)
+	if !*noUI && strings.HasPrefix(addrs[0], ":") {
+		log.Printf("UI: http://localhost%s/ui/", addrs[0])
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-errc:
+		log.Printf("server: %v", err)
+	case v := <-sig:
+		log.Printf("Received %v, shutting down", v)
+	}
+
+	// Long-lived SSE connections never end on their own and would hold
+	// Shutdown open; tell them to emit a final shutdown event and hang up.
+	storage.announceShutdown()
+	for _, root := range roots {
+		root.announceShutdown()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			// Deadline passed with connections still open: cancel their
+			// request contexts and force-close.
+			cancelBase()
+			srv.Close()
+		}
+	}
+}
+
+// Cache policies, one per endpoint class. Live responses (SSE, tails,
+// anything that follows a growing file) must never be cached; listings
+// and derived views go stale within seconds; exports are content-hashed
+// via ETag so intermediaries may hold them longer and revalidate.
+const (
+	cacheLive   = "no-store"
+	cacheBrief  = "private, max-age=5"
+	cacheExport = "private, max-age=3600"
+)
+
+// resetHeaderWriter stamps Stream-Reset-Required just before the first
+// byte of the response, since storage.Read sets the flag after the
+// handler has already started.
+type resetHeaderWriter struct {
+	http.ResponseWriter
+	flag *bool
+}
+
+func (w *resetHeaderWriter) WriteHeader(code int) {
+	if *w.flag {
+		w.Header().Set("Stream-Reset-Required", "true")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *resetHeaderWriter) Write(b []byte) (int, error) {
+	if *w.flag {
+		w.Header().Set("Stream-Reset-Required", "true")
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps SSE/long-poll responses streaming through the wrapper.
+func (w *resetHeaderWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
 
-	if err := http.ListenAndServe(*addr, handler); err != nil {
-		log.Fatalf("server: %v", err)
+// rangeHeaderWriter stamps Stream-Content-Range just before the first
+// byte of the response: "bytes <start>-<end>/<total>", where end is the
+// exclusive offset to resume from and total is the stream size.
+type rangeHeaderWriter struct {
+	http.ResponseWriter
+	rng *readRange
+}
+
+func (w *rangeHeaderWriter) stamp() {
+	if w.rng.set {
+		w.Header().Set("Stream-Content-Range",
+			fmt.Sprintf("bytes %d-%d/%d", w.rng.start, w.rng.end, w.rng.total))
+	}
+}
+
+func (w *rangeHeaderWriter) WriteHeader(code int) {
+	w.stamp()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *rangeHeaderWriter) Write(b []byte) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps SSE/long-poll responses streaming through the wrapper.
+func (w *rangeHeaderWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// digestHeaderWriter stamps Stream-Digest just before the first byte of
+// the response and enforces the Stream-If-Digest precondition: when the
+// client's asserted digest no longer matches what Read produced, the
+// response becomes a 412 instead of silently shifted bytes.
+type digestHeaderWriter struct {
+	http.ResponseWriter
+	digest   *readDigest
+	ifDigest string
+	wrote    bool
+	failed   bool
+}
+
+func (w *digestHeaderWriter) WriteHeader(code int) {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	if w.digest.set {
+		sum := w.digest.String()
+		if code < 300 && w.ifDigest != "" && w.ifDigest != sum {
+			w.failed = true
+			apiError(w.ResponseWriter, http.StatusPreconditionFailed, codePreconditionFailed,
+				"content at this offset changed (digest mismatch); restart the download")
+			return
+		}
+		w.Header().Set("Stream-Digest", sum)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *digestHeaderWriter) Write(b []byte) (int, error) {
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.failed {
+		// The 412 envelope already went out; drop the stale payload.
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps SSE/long-poll responses streaming through the wrapper.
+func (w *digestHeaderWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
 	}
 }
 
+// cachePolicy stamps a Cache-Control policy before delegating to the
+// endpoint handler.
+func cachePolicy(value string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", value)
+		h(w, r)
+	}
+}
+
+// pageEnvelope is the shared response shape for list endpoints:
+// {"items": [...], "next_cursor": "...", "total": N}. Total counts all
+// matching items, not just this page; next_cursor is empty on the last
+// page.
+type pageEnvelope struct {
+	Items      any    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+// encodeCursor wraps a pagination position in an opaque token. Clients
+// must treat cursors as opaque; the encoding may change.
+func encodeCursor(pos string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pos))
+}
+
+// decodeCursor unwraps a cursor produced by encodeCursor.
+func decodeCursor(c string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(c)
+	return string(b), err
+}
+
+// stringList collects repeated flag values (-addr, -extra-file).
+type stringList []string
+
+func (a *stringList) String() string { return strings.Join(*a, ",") }
+
+func (a *stringList) Set(v string) error {
+	*a = append(*a, v)
+	return nil
+}
+
+// printCheckSummary reports what the given storage would serve: how
+// many conversations across how many projects, and whether command
+// history is present. Index warnings have already been logged by
+// construction at this point.
+func printCheckSummary(s *ClaudeStorage, dir string) {
+	s.mu.RLock()
+	conversations := 0
+	projects := map[string]struct{}{}
+	for id, path := range s.fileIndex {
+		if id == "_history" {
+			continue
+		}
+		conversations++
+		projects[filepath.Dir(path)] = struct{}{}
+	}
+	s.mu.RUnlock()
+
+	fmt.Printf("Directory: %s\n", dir)
+	fmt.Printf("Conversations: %d\n", conversations)
+	fmt.Printf("Projects: %d\n", len(projects))
+	if _, err := os.Stat(filepath.Join(s.claudeDir, "history.jsonl")); err == nil {
+		fmt.Println("History: present")
+	} else {
+		fmt.Println("History: absent")
+	}
+	fmt.Println("OK")
+}
+
+// detectClaudeDir finds the Claude directory when -dir is unset. It
+// checks $CLAUDE_HOME, ~/.claude, $XDG_CONFIG_HOME/claude and the
+// platform config dir, picking the first that contains a projects
+// subdirectory.
+func detectClaudeDir() (string, error) {
+	var candidates []string
+	if v := os.Getenv("CLAUDE_HOME"); v != "" {
+		candidates = append(candidates, v)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".claude"))
+	}
+	if v := os.Getenv("XDG_CONFIG_HOME"); v != "" {
+		candidates = append(candidates, filepath.Join(v, "claude"))
+	}
+	if cfg, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(cfg, "claude"))
+	}
+
+	for _, dir := range candidates {
+		if info, err := os.Stat(filepath.Join(dir, "projects")); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no Claude directory with a projects subdirectory found; checked: %s (use -dir to set one explicitly)", strings.Join(candidates, ", "))
+}
+
+// uiFilesystem returns the filesystem to serve the UI from. When dir is
+// non-empty it must contain an index.html; otherwise the embedded build
+// is used.
+func uiFilesystem(dir string) (fs.FS, error) {
+	if dir == "" {
+		return fs.Sub(webUI, "webui/dist")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+		return nil, fmt.Errorf("ui dir %s: %w", dir, err)
+	}
+	log.Printf("Serving UI from %s", dir)
+	return os.DirFS(dir), nil
+}
+
+// parseReadLimits builds per-request read limits from the limit,
+// max_messages and skip query parameters. A plain (non-live) GET renders
+// the whole page as one JSON array, which large pages make slow to
+// parse; it gets arrayDefault as a smaller byte limit than the streaming
+// modes. An explicit ?limit always wins.
+func parseReadLimits(r *http.Request, arrayDefault int) (*readLimits, error) {
+	rl := &readLimits{}
+	q := r.URL.Query()
+	var err error
+	if v := q.Get("limit"); v != "" {
+		rl.bytes, err = strconv.Atoi(v)
+		if err != nil || rl.bytes <= 0 {
+			return nil, fmt.Errorf("invalid limit")
+		}
+	}
+	if v := q.Get("max_messages"); v != "" {
+		rl.messages, err = strconv.Atoi(v)
+		if err != nil || rl.messages <= 0 {
+			return nil, fmt.Errorf("invalid max_messages")
+		}
+	}
+	if v := q.Get("skip"); v != "" {
+		rl.skip, err = strconv.Atoi(v)
+		if err != nil || rl.skip < 0 {
+			return nil, fmt.Errorf("invalid skip")
+		}
+	}
+	if rl.bytes == 0 && q.Get("live") == "" {
+		rl.bytes = arrayDefault
+	}
+	return rl, nil
+}
+
+// setupUI serves the UI at /ui/, either from -ui-dir or the embedded
+// build. noUI leaves the route off entirely for API-only deployments.
+func setupUI(mux *http.ServeMux, noUI bool, dir string) error {
+	if noUI {
+		return nil
+	}
+	uiFS, err := uiFilesystem(dir)
+	if err != nil {
+		return err
+	}
+	mux.Handle("/ui/", http.StripPrefix("/ui/", spaHandler(http.FileServer(http.FS(uiFS)))))
+	return nil
+}
+
 // spaHandler wraps a file server to serve index.html for SPA routes
 func spaHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -92,14 +722,73 @@ func spaHandler(h http.Handler) http.Handler {
 	})
 }
 
+// apiVersion is reported in the X-Claude2Stream-Version header and as
+// the /v1/ path prefix.
+const apiVersion = "1"
+
+// apiVersionMiddleware stamps the API version header on every response
+// and strips the canonical /v1/ prefix so both prefixed and legacy
+// unprefixed paths resolve during the deprecation window. UI paths are
+// never versioned.
+func apiVersionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Claude2Stream-Version", apiVersion)
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/v"+apiVersion+"/"); ok {
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = "/" + rest
+			next.ServeHTTP(w, r2)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestIDMiddleware echoes the client's X-Request-ID (generating one
+// when absent) on the response and threads it through the context, so
+// audit entries and client logs can be correlated end to end.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(withRequestID(r.Context(), id)))
+	})
+}
+
+// requireAdmin gates a handler behind the -admin-token flag. Admin
+// endpoints are disabled entirely when no token is configured.
+func requireAdmin(token string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			apiError(w, http.StatusForbidden, codeForbidden, "admin endpoints disabled")
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			apiError(w, http.StatusUnauthorized, codeUnauthorized, "unauthorized")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// corsMaxAge is how long browsers may cache a CORS preflight response.
+// Set by -cors-max-age; without it every request pays a preflight
+// round trip.
+var corsMaxAge = 600
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Stream-Offset, Accept")
-		w.Header().Set("Access-Control-Expose-Headers", "Stream-Next-Offset, Stream-Tail-Offset")
+		// POST covers /_metadata batch lookups and PUT the bookmark
+		// store; everything else is read-only GET/HEAD.
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Stream-Offset, Stream-If-Digest, Accept")
+		w.Header().Set("Access-Control-Expose-Headers", "Stream-Next-Offset, Stream-Tail-Offset, Stream-Digest, Stream-Content-Range, X-Request-ID")
 
 		if r.Method == "OPTIONS" {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
 			w.WriteHeader(http.StatusOK)
 			return
 		}
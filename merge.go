@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// Merged reads present a resume chain (see chain.go) as one logical
+// stream: the files are concatenated oldest-first and offsets count
+// bytes across the whole concatenation, so a client can page through a
+// resumed session without knowing where one file ends and the next
+// begins.
+
+type mergedReadKey struct{}
+
+// withMergedRead marks the request as wanting ?merge=1 chain
+// concatenation applied to Read.
+func withMergedRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, mergedReadKey{}, true)
+}
+
+func mergedReadFrom(ctx context.Context) bool {
+	v, _ := ctx.Value(mergedReadKey{}).(bool)
+	return v
+}
+
+// chainPaths returns the file paths of a conversation's resume chain,
+// oldest first, ending with the stream's own file. Conversations that
+// were never resumed yield a single-element slice. Broken links end the
+// walk; visited tracking guards against cycles.
+func (s *ClaudeStorage) chainPaths(streamID string) []string {
+	path, err := s.getPath(streamID)
+	if err != nil {
+		return nil
+	}
+
+	paths := []string{path}
+	visited := map[string]bool{streamID: true}
+	for {
+		parent := sessionParent(paths[0])
+		if parent == "" || visited[parent] {
+			break
+		}
+		parentPath, err := s.getPath(parent)
+		if err != nil {
+			break
+		}
+		visited[parent] = true
+		paths = append([]string{parentPath}, paths...)
+	}
+	return paths
+}
+
+// readMerged reads across a chain of files as if they were one. The
+// files are already ordered oldest-first, which matches timestamp order
+// since a continuation always starts after its parent ends.
+func (s *ClaudeStorage) readMerged(ctx context.Context, paths []string, offset durablestream.Offset, limit int) (*durablestream.ReadResult, error) {
+	sizes := make([]int64, len(paths))
+	var total int64
+	for i, path := range paths {
+		// Concatenation offsets count uncompressed bytes, matching what
+		// the per-file scan below sees through openStream.
+		if isGzipPath(path) {
+			size, err := s.uncompressedSize(path)
+			if err != nil {
+				return nil, fmt.Errorf("size: %w", err)
+			}
+			sizes[i] = size
+		} else {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, fmt.Errorf("stat: %w", err)
+			}
+			sizes[i] = info.Size()
+		}
+		total += sizes[i]
+	}
+
+	start := offsetToInt(offset)
+	var (
+		messages  []durablestream.StoredMessage
+		bytesRead int
+		base      int64
+	)
+
+	for i, path := range paths {
+		if bytesRead >= limit {
+			break
+		}
+		if start >= base+sizes[i] {
+			base += sizes[i]
+			continue
+		}
+
+		local := start - base
+		if local < 0 {
+			local = 0
+		}
+		f, err := openStream(path)
+		if err != nil {
+			return nil, fmt.Errorf("open: %w", err)
+		}
+		if _, err := io.CopyN(io.Discard, f, local); err != nil && err != io.EOF {
+			f.Close()
+			return nil, fmt.Errorf("seek: %w", err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		buf := make([]byte, 0, 64*1024)
+		scanner.Buffer(buf, 16*1024*1024)
+		scanner.Split(scanLinesKeepCR)
+
+		lines := 0
+		for scanner.Scan() {
+			lines++
+			if lines%ctxCheckEvery == 0 && ctx.Err() != nil {
+				f.Close()
+				return nil, ctx.Err()
+			}
+			line := scanner.Bytes()
+			local += int64(len(line) + 1)
+
+			data := bytes.TrimSuffix(line, []byte{'\r'})
+			if !json.Valid(data) {
+				continue
+			}
+			messages = append(messages, durablestream.StoredMessage{
+				Data:   append([]byte(nil), data...),
+				Offset: offsetFromInt(base + local),
+			})
+			bytesRead += len(data)
+			if bytesRead >= limit {
+				break
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		base += sizes[i]
+	}
+
+	nextOffset := offset
+	if len(messages) > 0 {
+		nextOffset = messages[len(messages)-1].Offset
+	}
+	return &durablestream.ReadResult{
+		Messages:   messages,
+		NextOffset: nextOffset,
+		TailOffset: offsetFromInt(total),
+	}, nil
+}
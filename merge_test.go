@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestMergedReadAcrossContinuation(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	parentID, childID := testUUID(1), testUUID(2)
+	parentLines := []string{
+		userEntry(testUUID(10), "before compaction"),
+		assistantEntry(testUUID(11), "test-model", "parent answer"),
+	}
+	childLines := []string{
+		fmt.Sprintf(`{"parentSessionId":%q,"type":"user","uuid":%q,"message":{"role":"user","content":"after compaction"}}`,
+			parentID, testUUID(12)),
+		assistantEntry(testUUID(13), "test-model", "child answer"),
+	}
+	writeStream(t, projectDir, parentID, parentLines...)
+	writeStream(t, projectDir, childID, childLines...)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := withMergedRead(context.Background())
+	result, err := s.Read(ctx, childID, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("merged read: %v", err)
+	}
+	if len(result.Messages) != 4 {
+		t.Fatalf("got %d messages, want parent + child = 4", len(result.Messages))
+	}
+	if string(result.Messages[0].Data) != parentLines[0] {
+		t.Errorf("first message = %s, want the parent's opening line", result.Messages[0].Data)
+	}
+	if string(result.Messages[3].Data) != childLines[1] {
+		t.Errorf("last message = %s, want the child's final line", result.Messages[3].Data)
+	}
+
+	// Offsets count bytes across the concatenation, so the last offset is
+	// the combined size of both files.
+	var total int64
+	for _, l := range append(parentLines, childLines...) {
+		total += int64(len(l) + 1)
+	}
+	if got := offsetToInt(result.Messages[3].Offset); got != total {
+		t.Errorf("final offset = %d, want combined size %d", got, total)
+	}
+	if got := offsetToInt(result.TailOffset); got != total {
+		t.Errorf("tail offset = %d, want %d", got, total)
+	}
+
+	// Resuming from the parent/child boundary returns only child lines.
+	var parentSize int64
+	for _, l := range parentLines {
+		parentSize += int64(len(l) + 1)
+	}
+	result, err = s.Read(ctx, childID, offsetFromInt(parentSize), maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 2 || string(result.Messages[0].Data) != childLines[0] {
+		t.Errorf("resumed read = %d messages starting %s, want the child file", len(result.Messages), result.Messages[0].Data)
+	}
+}
+
+func TestMergedReadWithoutChain(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := userEntry(testUUID(10), "standalone")
+	writeStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+
+	// A conversation that was never resumed reads the same merged or not.
+	result, err := s.Read(withMergedRead(context.Background()), id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0].Data) != line {
+		t.Errorf("merged single-file read = %+v", result.Messages)
+	}
+}
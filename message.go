@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// handleMessage fetches exactly one message by its byte offset: the
+// line beginning there, plus the offset to continue from. A precise
+// random-access primitive for clients holding offsets from earlier
+// reads.
+//
+// GET /_message/{streamID}?offset=<offset>
+func (s *ClaudeStorage) handleMessage(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_message/")
+
+	v := r.URL.Query().Get("offset")
+	if v == "" {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "missing offset")
+		return
+	}
+	off, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || off < 0 {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "invalid offset")
+		return
+	}
+
+	path, err := s.getPathCtx(r.Context(), streamID)
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+
+	// The offset must name a line start; anything mid-line would return
+	// a JSON fragment that parses as garbage or not at all.
+	offsets, err := s.lineOffsets(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "index stream")
+		return
+	}
+	i := sort.Search(len(offsets), func(i int) bool { return offsets[i] >= off })
+	if i == len(offsets) || offsets[i] != off {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "offset is not a message boundary")
+		return
+	}
+
+	// Read with a one-message cap does the actual fetch, so all the
+	// usual transforms and throttles apply.
+	ctx := withReadLimits(r.Context(), &readLimits{messages: 1})
+	result, err := s.Read(ctx, streamID, offsetFromInt(off), maxReadLimitBytes)
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+	if len(result.Messages) == 0 {
+		apiErrorFrom(w, ErrMessageNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Message    json.RawMessage `json:"message"`
+		NextOffset string          `json:"nextOffset"`
+	}{
+		Message:    json.RawMessage(result.Messages[0].Data),
+		NextOffset: string(result.Messages[0].Offset),
+	})
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// streamMetadata summarizes one conversation for listing UIs.
+type streamMetadata struct {
+	ID           string    `json:"id"`
+	TailOffset   string    `json:"tailOffset"`
+	Title        string    `json:"title,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	MessageCount int       `json:"messageCount"`
+	LastModified time.Time `json:"lastModified"`
+	Sidechain    bool      `json:"sidechain,omitempty"`
+	Pinned       bool      `json:"pinned,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// metadataCache memoizes extracted metadata keyed by file path. Entries
+// are invalidated when the file's size or mtime changes, so we only pay
+// the full-file scan once per write.
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]metadataCacheEntry
+}
+
+type metadataCacheEntry struct {
+	size    int64
+	modTime time.Time
+	meta    streamMetadata
+}
+
+const maxTitleLen = 80
+
+// metadata returns cached-or-extracted metadata for a stream.
+func (s *ClaudeStorage) metadata(streamID string) (*streamMetadata, error) {
+	path, err := s.getPath(streamID)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.metaCache.mu.Lock()
+	if e, ok := s.metaCache.entries[path]; ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		s.metaCache.mu.Unlock()
+		meta := e.meta
+		meta.ID = streamID
+		applySidecar(&meta, path)
+		s.applyAnnotation(&meta)
+		return &meta, nil
+	}
+	s.metaCache.mu.Unlock()
+
+	meta, err := extractMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	meta.ID = streamID
+	meta.TailOffset = string(offsetFromInt(info.Size()))
+	meta.LastModified = info.ModTime()
+
+	s.metaCache.mu.Lock()
+	s.metaCache.entries[path] = metadataCacheEntry{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		meta:    *meta,
+	}
+	s.metaCache.mu.Unlock()
+
+	applySidecar(meta, path)
+	s.applyAnnotation(meta)
+	return meta, nil
+}
+
+// applySidecar overlays user-provided sidecar annotations onto extracted
+// metadata. Sidecars can change independently of the conversation file,
+// so this runs outside the mtime-keyed cache.
+func applySidecar(meta *streamMetadata, path string) {
+	if sc := readSidecar(path); sc != nil && sc.Title != "" {
+		meta.Title = truncate(sc.Title, maxTitleLen)
+	}
+}
+
+// extractMetadata scans a conversation file for its title (first user
+// text), model (last assistant model seen) and message count.
+func extractMetadata(path string) (*streamMetadata, error) {
+	f, err := openStream(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	meta := &streamMetadata{}
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var entry struct {
+			Type        string `json:"type"`
+			IsSidechain bool   `json:"isSidechain"`
+			Message     struct {
+				Model   string          `json:"model"`
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		meta.MessageCount++
+
+		if entry.IsSidechain {
+			meta.Sidechain = true
+		}
+		if entry.Message.Model != "" {
+			meta.Model = entry.Message.Model
+		}
+		if meta.Title == "" && entry.Type == "user" {
+			if text := contentText(entry.Message.Content); text != "" {
+				meta.Title = truncate(text, maxTitleLen)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// maxMetadataBatch caps how many stream IDs one /_metadata request may ask for.
+const maxMetadataBatch = 200
+
+// handleMetadataBatch returns metadata for many streams in one round trip.
+// Unknown IDs get a per-entry error instead of failing the whole batch.
+//
+// POST /_metadata with body ["id1", "id2", ...]
+func (s *ClaudeStorage) handleMetadataBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apiError(w, http.StatusMethodNotAllowed, codeMethodNotAllow, "method not allowed")
+		return
+	}
+
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "invalid request body (want JSON array of stream IDs)")
+		return
+	}
+	if len(ids) > maxMetadataBatch {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "too many stream IDs")
+		return
+	}
+
+	result := make(map[string]*streamMetadata, len(ids))
+	for _, id := range ids {
+		meta, err := s.metadata(id)
+		if err != nil {
+			result[id] = &streamMetadata{ID: id, Error: err.Error()}
+			continue
+		}
+		result[id] = meta
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleListStreams lists all indexed conversations with metadata,
+// newest first, in the standard pagination envelope. Sidechain/subagent
+// transcripts can be hidden with ?top_level_only=1; ?page_size=N with
+// the returned next_cursor pages through large listings.
+//
+// GET /_streams[?top_level_only=1][&page_size=N][&cursor=...]
+func (s *ClaudeStorage) handleListStreams(w http.ResponseWriter, r *http.Request) {
+	topLevelOnly := r.URL.Query().Get("top_level_only") == "1"
+
+	// A listing taken before the initial index completes may be missing
+	// conversations; flag it so clients can retry.
+	if !s.ready.Load() {
+		w.Header().Set("X-Index-Warming", "true")
+	}
+
+	pageSize := 0
+	if v := r.URL.Query().Get("page_size"); v != "" {
+		var err error
+		pageSize, err = strconv.Atoi(v)
+		if err != nil || pageSize <= 0 {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid page_size")
+			return
+		}
+	}
+	cursor := ""
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		var err error
+		cursor, err = decodeCursor(c)
+		if err != nil {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid cursor")
+			return
+		}
+	}
+
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.fileIndex))
+	for id := range s.fileIndex {
+		if id == "_history" {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	streams := make([]*streamMetadata, 0, len(ids))
+	for _, id := range ids {
+		meta, err := s.metadata(id)
+		if err != nil {
+			continue // went away since indexing
+		}
+		if topLevelOnly && meta.Sidechain {
+			continue
+		}
+		streams = append(streams, meta)
+	}
+
+	// Pinned conversations lead regardless of recency.
+	sort.Slice(streams, func(i, j int) bool {
+		if streams[i].Pinned != streams[j].Pinned {
+			return streams[i].Pinned
+		}
+		return streams[i].LastModified.After(streams[j].LastModified)
+	})
+	total := len(streams)
+
+	// The cursor names the last item of the previous page; resume just
+	// past it. Position is modTime+ID so it stays stable as new
+	// conversations arrive at the top of the listing.
+	if cursor != "" {
+		start := 0
+		for i, meta := range streams {
+			if listPosition(meta) == cursor {
+				start = i + 1
+				break
+			}
+		}
+		streams = streams[start:]
+	}
+
+	nextCursor := ""
+	if pageSize > 0 && len(streams) > pageSize {
+		streams = streams[:pageSize]
+		nextCursor = encodeCursor(listPosition(streams[len(streams)-1]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pageEnvelope{
+		Items:      streams,
+		NextCursor: nextCursor,
+		Total:      total,
+	})
+}
+
+// listPosition is the stable pagination position of a listed stream.
+func listPosition(meta *streamMetadata) string {
+	return strconv.FormatInt(meta.LastModified.UnixNano(), 10) + ":" + meta.ID
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetadataBatch(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	idA, idB := testUUID(1), testUUID(2)
+	writeStream(t, projectDir, idA,
+		userEntry(testUUID(3), "first conversation"),
+		assistantEntry(testUUID(4), "test-model", "reply"),
+	)
+	writeStream(t, projectDir, idB, userEntry(testUUID(5), "second conversation"))
+	s := newTestStorage(t, claudeDir)
+
+	missing := testUUID(9)
+	body := `["` + idA + `","` + idB + `","` + missing + `"]`
+	r := httptest.NewRequest("POST", "/_metadata", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.handleMetadataBatch(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var result map[string]streamMetadata
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 3 {
+		t.Fatalf("got %d entries, want 3", len(result))
+	}
+	if got := result[idA]; got.MessageCount != 2 || got.Model != "test-model" ||
+		!strings.Contains(got.Title, "first conversation") {
+		t.Errorf("metadata for %s = %+v", idA, got)
+	}
+	if got := result[idB]; got.MessageCount != 1 {
+		t.Errorf("metadata for %s = %+v", idB, got)
+	}
+	// An unknown ID fails its own entry, not the batch.
+	if got := result[missing]; got.Error == "" {
+		t.Errorf("missing stream should carry a per-entry error, got %+v", got)
+	}
+
+	// GET is not a batch lookup.
+	r = httptest.NewRequest("GET", "/_metadata", nil)
+	w = httptest.NewRecorder()
+	s.handleMetadataBatch(w, r)
+	if w.Code != 405 {
+		t.Errorf("GET status = %d, want 405", w.Code)
+	}
+
+	// Over the batch cap.
+	ids := make([]string, maxMetadataBatch+1)
+	for i := range ids {
+		ids[i] = testUUID(100 + i)
+	}
+	big, _ := json.Marshal(ids)
+	r = httptest.NewRequest("POST", "/_metadata", strings.NewReader(string(big)))
+	w = httptest.NewRecorder()
+	s.handleMetadataBatch(w, r)
+	if w.Code != 400 {
+		t.Errorf("oversized batch status = %d, want 400", w.Code)
+	}
+}
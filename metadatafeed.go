@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Live metadata feed: listing UIs subscribe once instead of re-polling
+// /_streams, and only the stream that actually changed is recomputed.
+
+// metaFeedDebounce batches the burst of watcher events a single write
+// tends to produce into one update per stream.
+const metaFeedDebounce = 250 * time.Millisecond
+
+// metadataUpdate is one SSE event on /_metadata/feed: the stream's
+// refreshed listing metadata plus a short preview of its newest message.
+type metadataUpdate struct {
+	*streamMetadata
+	Preview string `json:"preview,omitempty"`
+}
+
+// publishMetaChange fans a changed stream ID out to /_metadata/feed
+// listeners. Sends never block the watch loop; slow listeners just miss
+// updates and catch up on the next change.
+func (s *ClaudeStorage) publishMetaChange(streamID string) {
+	s.mu.RLock()
+	subs := s.metaFeedSubs
+	s.mu.RUnlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- streamID:
+		default:
+		}
+	}
+}
+
+// lastMessagePreview extracts display text from the stream's final
+// message, truncated like listing titles.
+func (s *ClaudeStorage) lastMessagePreview(streamID string) string {
+	path, err := s.getPath(streamID)
+	if err != nil {
+		return ""
+	}
+	lines, err := readLastLines(path, 1)
+	if err != nil || len(lines) == 0 {
+		return ""
+	}
+	return truncate(contentText(extractMessageContent(lines[0].Data)), maxTitleLen)
+}
+
+// handleMetadataFeed pushes a metadata update over SSE whenever a
+// conversation changes. Updates within the debounce window coalesce to
+// one event per stream.
+//
+// GET /_metadata/feed
+func (s *ClaudeStorage) handleMetadataFeed(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apiError(w, http.StatusInternalServerError, codeInternal, "streaming unsupported")
+		return
+	}
+
+	ch := make(chan string, 64)
+	s.mu.Lock()
+	s.metaFeedSubs = append(s.metaFeedSubs, ch)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		for i, c := range s.metaFeedSubs {
+			if c == ch {
+				s.metaFeedSubs = append(s.metaFeedSubs[:i], s.metaFeedSubs[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher.Flush()
+
+	// Changed IDs accumulate until the debounce timer fires, so a rapid
+	// write burst surfaces as one recompute per affected stream.
+	pending := make(map[string]bool)
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.shutdownCh:
+			fmt.Fprintf(w, "event: shutdown\ndata: {\"reason\":\"server shutting down\"}\n\n")
+			flusher.Flush()
+			return
+		case id := <-ch:
+			pending[id] = true
+			if timerC == nil {
+				timer = time.NewTimer(metaFeedDebounce)
+				timerC = timer.C
+			}
+		case <-timerC:
+			timerC = nil
+			for id := range pending {
+				meta, err := s.metadata(id)
+				if err != nil {
+					continue // deleted since the event
+				}
+				data, err := json.Marshal(metadataUpdate{
+					streamMetadata: meta,
+					Preview:        s.lastMessagePreview(id),
+				})
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: metadata\ndata: %s\n\n", data)
+			}
+			flusher.Flush()
+			pending = make(map[string]bool)
+		}
+	}
+}
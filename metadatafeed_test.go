@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadataFeedPushesUpdates(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "feed me updates"))
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/_metadata/feed", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		s.handleMetadataFeed(w, r)
+		close(done)
+	}()
+
+	// Wait for the subscriber to register, then announce a change.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.RLock()
+		n := len(s.metaFeedSubs)
+		s.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("feed subscriber never registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.publishMetaChange(id)
+
+	// One debounce window later the update has been written.
+	time.Sleep(metaFeedDebounce + 200*time.Millisecond)
+	cancel()
+	<-done
+
+	body := w.Body.String()
+	if !strings.Contains(body, "event: metadata") {
+		t.Fatalf("no metadata event in feed:\n%s", body)
+	}
+	if !strings.Contains(body, id) {
+		t.Errorf("event missing stream ID:\n%s", body)
+	}
+	if !strings.Contains(body, "feed me updates") {
+		t.Errorf("event missing last-message preview:\n%s", body)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// Benchmark the -mmap read path against the default buffered one over a
+// conversation large enough for the difference to show. Run with:
+//
+//	go test -bench BenchmarkRead -benchmem
+func benchmarkRead(b *testing.B, mmap bool) {
+	dir := b.TempDir()
+	lines := make([]string, 2000)
+	for i := range lines {
+		lines[i] = assistantEntry(testUUID(i), "test-model",
+			"a response message padded out to a few hundred bytes so the file is dominated by payload rather than envelope -------------------------------------------------------------")
+	}
+	path := writeStream(b, dir, "bench", lines...)
+	s := newSingleFileStorage(b, path)
+
+	old := useMmap
+	useMmap = mmap
+	b.Cleanup(func() { useMmap = old })
+
+	var total int64
+	for _, line := range lines {
+		total += int64(len(line) + 1)
+	}
+	b.SetBytes(total)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		result, err := s.Read(context.Background(), "bench", durablestream.ZeroOffset, maxReadLimitBytes)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(result.Messages) != len(lines) {
+			b.Fatalf("got %d messages, want %d", len(result.Messages), len(lines))
+		}
+	}
+}
+
+func BenchmarkReadBuffered(b *testing.B) { benchmarkRead(b, false) }
+func BenchmarkReadMmap(b *testing.B)     { benchmarkRead(b, true) }
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile is a stub on platforms without mmap support; reads fall back
+// to regular file I/O.
+type mmapFile struct {
+	data []byte
+}
+
+func mapFile(f *os.File, size int64) (*mmapFile, error) {
+	return nil, errors.New("mmap not supported on this platform")
+}
+
+func (m *mmapFile) Close() error { return nil }
@@ -0,0 +1,39 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile is a read-only memory mapping of a conversation file.
+type mmapFile struct {
+	data []byte
+}
+
+// mapFile maps size bytes of f read-only. The mapping is taken at the
+// file's current size, so bytes appended afterwards are simply not part
+// of this mapping and are picked up by the next Read.
+func mapFile(f *os.File, size int64) (*mmapFile, error) {
+	if size == 0 {
+		// Mapping zero bytes is an error on most platforms; an empty
+		// file needs no mapping at all.
+		return &mmapFile{}, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return &mmapFile{data: data}, nil
+}
+
+// Close releases the mapping.
+func (m *mmapFile) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	data := m.data
+	m.data = nil
+	return syscall.Munmap(data)
+}
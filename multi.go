@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// multiStorage routes alias-prefixed stream IDs ("alice/<uuid>") to the
+// ClaudeStorage for that root, as configured by the -dirs flag. IDs
+// without a known alias go to the default root, so single-root URLs keep
+// working unchanged.
+type multiStorage struct {
+	def   *ClaudeStorage
+	roots map[string]*ClaudeStorage // alias -> storage
+}
+
+// parseRoots parses the -dirs flag value ("alice=/home/alice/.claude,...")
+// into per-alias storages.
+func parseRoots(spec string) (map[string]*ClaudeStorage, error) {
+	roots := make(map[string]*ClaudeStorage)
+	for _, pair := range strings.Split(spec, ",") {
+		alias, dir, ok := strings.Cut(pair, "=")
+		if !ok || alias == "" || dir == "" {
+			return nil, fmt.Errorf("invalid -dirs entry %q (want alias=path)", pair)
+		}
+		if strings.Contains(alias, "/") {
+			return nil, fmt.Errorf("invalid alias %q (must not contain '/')", alias)
+		}
+		if _, dup := roots[alias]; dup {
+			return nil, fmt.Errorf("duplicate alias %q", alias)
+		}
+		st, err := NewClaudeStorage(dir)
+		if err != nil {
+			return nil, fmt.Errorf("root %s: %w", alias, err)
+		}
+		roots[alias] = st
+		log.Printf("Root %q: %s", alias, dir)
+	}
+	return roots, nil
+}
+
+// resolve splits an alias prefix off a stream ID and returns the storage
+// responsible for it plus the remaining ID.
+func (m *multiStorage) resolve(streamID string) (*ClaudeStorage, string) {
+	id := strings.TrimPrefix(streamID, "/")
+	if alias, rest, ok := strings.Cut(id, "/"); ok {
+		if st, found := m.roots[alias]; found {
+			return st, rest
+		}
+	}
+	return m.def, id
+}
+
+func (m *multiStorage) Create(ctx context.Context, streamID string, cfg durablestream.StreamConfig) (bool, error) {
+	st, id := m.resolve(streamID)
+	return st.Create(ctx, id, cfg)
+}
+
+func (m *multiStorage) Append(ctx context.Context, streamID string, data []byte, seq string) (durablestream.Offset, error) {
+	st, id := m.resolve(streamID)
+	return st.Append(ctx, id, data, seq)
+}
+
+func (m *multiStorage) AppendFrom(ctx context.Context, streamID string, r io.Reader, seq string) (durablestream.Offset, error) {
+	st, id := m.resolve(streamID)
+	return st.AppendFrom(ctx, id, r, seq)
+}
+
+func (m *multiStorage) Delete(ctx context.Context, streamID string) error {
+	st, id := m.resolve(streamID)
+	return st.Delete(ctx, id)
+}
+
+func (m *multiStorage) Head(ctx context.Context, streamID string) (*durablestream.StreamInfo, error) {
+	st, id := m.resolve(streamID)
+	return st.Head(ctx, id)
+}
+
+func (m *multiStorage) Read(ctx context.Context, streamID string, offset durablestream.Offset, limit int) (*durablestream.ReadResult, error) {
+	st, id := m.resolve(streamID)
+	return st.Read(ctx, id, offset, limit)
+}
+
+func (m *multiStorage) Subscribe(ctx context.Context, streamID string, offset durablestream.Offset) (<-chan durablestream.Offset, error) {
+	st, id := m.resolve(streamID)
+	return st.Subscribe(ctx, id, offset)
+}
+
+func (m *multiStorage) Close() error {
+	err := m.def.Close()
+	for _, st := range m.roots {
+		if cerr := st.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
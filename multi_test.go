@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestMultiRootAliasResolution(t *testing.T) {
+	aliceDir, aliceProject := newTestClaudeDir(t)
+	bobDir, bobProject := newTestClaudeDir(t)
+	defDir, defProject := newTestClaudeDir(t)
+
+	// The same UUID exists under every root; only the alias prefix can
+	// disambiguate.
+	id := testUUID(1)
+	aliceLine := userEntry(testUUID(2), "alice's conversation")
+	bobLine := userEntry(testUUID(3), "bob's conversation")
+	defLine := userEntry(testUUID(4), "default conversation")
+	writeStream(t, aliceProject, id, aliceLine)
+	writeStream(t, bobProject, id, bobLine)
+	writeStream(t, defProject, id, defLine)
+
+	m := &multiStorage{
+		def: newTestStorage(t, defDir),
+		roots: map[string]*ClaudeStorage{
+			"alice": newTestStorage(t, aliceDir),
+			"bob":   newTestStorage(t, bobDir),
+		},
+	}
+
+	ctx := context.Background()
+	for prefix, want := range map[string]string{
+		"alice/" + id: aliceLine,
+		"bob/" + id:   bobLine,
+		id:            defLine, // no alias falls through to the default root
+	} {
+		result, err := m.Read(ctx, prefix, durablestream.ZeroOffset, maxReadLimitBytes)
+		if err != nil {
+			t.Fatalf("read %s: %v", prefix, err)
+		}
+		if len(result.Messages) != 1 || string(result.Messages[0].Data) != want {
+			t.Errorf("read %s = %+v, want %q", prefix, result.Messages, want)
+		}
+	}
+
+	// An unknown alias is not a root: the whole ID goes to the default,
+	// which doesn't have it.
+	if _, err := m.Read(ctx, "carol/"+id, durablestream.ZeroOffset, maxReadLimitBytes); err == nil {
+		t.Error("unknown alias resolved; want a not-found error")
+	}
+}
+
+func TestParseRootsRejectsBadSpecs(t *testing.T) {
+	for _, spec := range []string{"noequals", "=path", "alias=", "a/b=/tmp"} {
+		if _, err := parseRoots(spec); err == nil {
+			t.Errorf("parseRoots(%q) succeeded, want error", spec)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNoHistoryFlag(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	writeStream(t, projectDir, testUUID(1), userEntry(testUUID(2), "normal stream"))
+	historyLine := `{"display":"do the thing","timestamp":1700000000}`
+	if err := os.WriteFile(filepath.Join(claudeDir, "history.jsonl"), []byte(historyLine+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	old := noHistory
+	t.Cleanup(func() { noHistory = old })
+
+	// With the flag off, _history resolves.
+	noHistory = false
+	s := newTestStorage(t, claudeDir)
+	if _, err := s.getPath("_history"); err != nil {
+		t.Errorf("history unavailable without -no-history: %v", err)
+	}
+
+	// With the flag on, _history is gone everywhere: resolution,
+	// reads and the enriched endpoint.
+	noHistory = true
+	s = newTestStorage(t, claudeDir)
+	if _, err := s.getPath("_history"); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("getPath err = %v, want ErrStreamNotFound", err)
+	}
+	if _, err := s.Head(context.Background(), "_history"); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("Head err = %v, want ErrStreamNotFound", err)
+	}
+
+	r := httptest.NewRequest("GET", "/_history/enriched", nil)
+	w := httptest.NewRecorder()
+	s.handleHistoryEnriched(w, r)
+	if w.Code != 404 {
+		t.Errorf("enriched history status = %d, want 404", w.Code)
+	}
+
+	// Ordinary conversations are unaffected.
+	if _, err := s.getPath(testUUID(1)); err != nil {
+		t.Errorf("regular stream broken by -no-history: %v", err)
+	}
+}
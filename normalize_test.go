@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestNormalizeContentShapes(t *testing.T) {
+	stringForm := []byte(`{"type":"user","message":{"role":"user","content":"hello"}}`)
+	arrayForm := []byte(`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"hello"}]}}`)
+
+	decode := func(line []byte) any {
+		var v any
+		if err := json.Unmarshal(line, &v); err != nil {
+			t.Fatalf("bad normalized line %s: %v", line, err)
+		}
+		return v
+	}
+	got := decode(normalizeContent(stringForm))
+	want := decode(normalizeContent(arrayForm))
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("string and array content normalize differently:\n%v\n%v", got, want)
+	}
+
+	// Lines without a message pass through untouched.
+	summary := []byte(`{"type":"summary","summary":"a title"}`)
+	if string(normalizeContent(summary)) != string(summary) {
+		t.Errorf("summary line changed: %s", normalizeContent(summary))
+	}
+}
+
+func TestNormalizedReadMode(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := fmt.Sprintf(`{"type":"user","uuid":%q,"message":{"role":"user","content":"plain string"}}`, testUUID(2))
+	writeStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := withNormalize(context.Background())
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 {
+		t.Fatalf("got %d messages", len(result.Messages))
+	}
+	var entry struct {
+		Message struct {
+			Content []contentBlock `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(result.Messages[0].Data, &entry); err != nil {
+		t.Fatalf("normalized content is not an array: %v", err)
+	}
+	if len(entry.Message.Content) != 1 || entry.Message.Content[0].Text != "plain string" {
+		t.Errorf("normalized content = %+v, want one text block", entry.Message.Content)
+	}
+}
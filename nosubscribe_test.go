@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestNoSubscribeGlob(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := userEntry(testUUID(2), "read-only by policy")
+	writeStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+	s.noSubscribe = "*"
+
+	ctx := context.Background()
+	if _, err := s.Subscribe(ctx, id, durablestream.ZeroOffset); !errors.Is(err, ErrSubscribeDisabled) {
+		t.Fatalf("Subscribe err = %v, want ErrSubscribeDisabled", err)
+	}
+
+	// Reads are unaffected by the subscription policy.
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0].Data) != line {
+		t.Fatalf("read = %+v, want the stream's line", result.Messages)
+	}
+
+	// A non-matching glob leaves subscriptions alone.
+	s.noSubscribe = "_history"
+	if _, err := s.Subscribe(ctx, id, durablestream.ZeroOffset); err != nil {
+		t.Fatalf("Subscribe with non-matching glob: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNoUIServesAPIOnly(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "api still serves"))
+	s := newTestStorage(t, claudeDir)
+
+	mux := http.NewServeMux()
+	if err := setupUI(mux, true, ""); err != nil {
+		t.Fatalf("setupUI with -no-ui: %v", err)
+	}
+	mux.HandleFunc("/_tail/", s.handleTail)
+
+	// Without the /ui/ route the mux 404s UI paths outright.
+	for _, path := range []string{"/ui/", "/ui/some/route"} {
+		r := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != 404 {
+			t.Errorf("GET %s with -no-ui: status = %d, want 404", path, w.Code)
+		}
+	}
+
+	// The API routes are unaffected.
+	r := httptest.NewRequest("GET", "/_tail/"+id+"?n=1", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != 200 {
+		t.Fatalf("stream read with -no-ui: status = %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "api still serves") {
+		t.Errorf("stream read missing content:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// outlinePreviewLen is how much text each outline entry carries.
+const outlinePreviewLen = 80
+
+// outlineEntry is one turn in a conversation outline: just enough to
+// render a navigation minimap without the full content.
+type outlineEntry struct {
+	Role      string `json:"role"`
+	Preview   string `json:"preview"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Offset    string `json:"offset"`
+}
+
+// handleOutline returns the turn structure of a conversation: one entry
+// per message with role, a short text preview, timestamp and offset.
+// Pure tool traffic is labeled instead of previewed.
+//
+// GET /_outline/{streamID}
+func (s *ClaudeStorage) handleOutline(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_outline/")
+
+	path, err := s.getPath(streamID)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	ctx, cancel := scanContext(r.Context())
+	defer cancel()
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+	scanner.Split(scanLinesKeepCR)
+
+	var (
+		entries   []outlineEntry
+		offset    int64
+		truncated bool
+		lines     int
+	)
+	for scanner.Scan() {
+		lines++
+		if lines%ctxCheckEvery == 0 && ctx.Err() != nil {
+			truncated = true
+			break
+		}
+
+		line := scanner.Bytes()
+		offset += int64(len(line) + 1)
+
+		var entry struct {
+			Type      string `json:"type"`
+			Timestamp string `json:"timestamp"`
+			Message   struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		role := entry.Message.Role
+		if role == "" {
+			role = entry.Type
+		}
+		if role == "" {
+			continue
+		}
+
+		preview := contentText(entry.Message.Content)
+		if preview == "" {
+			if isTool, names, _ := toolActivity(line); isTool && len(names) > 0 {
+				preview = "[tool_use: " + strings.Join(names, ", ") + "]"
+			} else if isTool {
+				preview = "[tool_result]"
+			}
+		}
+		entries = append(entries, outlineEntry{
+			Role:      role,
+			Preview:   truncate(preview, outlinePreviewLen),
+			Timestamp: entry.Timestamp,
+			Offset:    string(offsetFromInt(offset)),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "scan stream")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Outline   []outlineEntry `json:"outline"`
+		Truncated bool           `json:"truncated,omitempty"`
+	}{entries, truncated})
+}
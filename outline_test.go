@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOutlineStructure(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	long := strings.Repeat("x", 200)
+	writeStream(t, projectDir, id,
+		userEntry(testUUID(2), "short question"),
+		assistantEntry(testUUID(3), "test-model", long),
+		toolUseEntry(testUUID(4), "Bash"),
+	)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_outline/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleOutline(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Outline []outlineEntry `json:"outline"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Outline) != 3 {
+		t.Fatalf("got %d entries, want 3", len(resp.Outline))
+	}
+	if resp.Outline[0].Role != "user" || resp.Outline[0].Preview != "short question" {
+		t.Errorf("first entry = %+v", resp.Outline[0])
+	}
+	if got := resp.Outline[1].Preview; len(got) > outlinePreviewLen+1 || !strings.HasPrefix(got, "xxx") {
+		t.Errorf("long preview = %q, want it truncated to ~%d chars", got, outlinePreviewLen)
+	}
+	if !strings.Contains(resp.Outline[2].Preview, "[tool_use: Bash]") {
+		t.Errorf("tool entry preview = %q, want a tool label", resp.Outline[2].Preview)
+	}
+
+	// Offsets point just past each line, so the last one is the file end.
+	if resp.Outline[0].Offset >= resp.Outline[1].Offset {
+		t.Errorf("offsets not increasing: %s then %s", resp.Outline[0].Offset, resp.Outline[1].Offset)
+	}
+}
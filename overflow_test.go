@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestOverflowTriggersRescan(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "recently active"))
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := s.Subscribe(ctx, id, durablestream.ZeroOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No file changes at all: only overflow recovery can produce a
+	// notification, by nudging subscribers of recently-modified streams
+	// whose events may have been among the dropped ones.
+	s.watcher.Errors <- fsnotify.ErrEventOverflow
+
+	select {
+	case _, ok := <-ch:
+		if !ok {
+			t.Fatal("subscription closed early")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("overflow did not trigger a rescan nudge")
+	}
+
+	s.mu.RLock()
+	dropped := s.droppedEvents
+	s.mu.RUnlock()
+	if dropped != 1 {
+		t.Errorf("droppedEvents = %d, want 1", dropped)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreflightRejectsNonDirectoryProjects(t *testing.T) {
+	claudeDir := t.TempDir()
+	// projects exists but is a plain file: refuse to start.
+	if err := os.WriteFile(filepath.Join(claudeDir, "projects"), []byte("oops"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewClaudeStorage(claudeDir)
+	if err == nil {
+		t.Fatal("expected an error for a non-directory projects path")
+	}
+	if !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("error %q is not descriptive", err)
+	}
+}
+
+func TestPreflightToleratesEmptyProjects(t *testing.T) {
+	claudeDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(claudeDir, "projects"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStorage(t, claudeDir)
+
+	// An empty tree warns but serves an empty index rather than failing.
+	s.mu.RLock()
+	n := 0
+	for id := range s.fileIndex {
+		if id != "_history" {
+			n++
+		}
+	}
+	s.mu.RUnlock()
+	if n != 0 {
+		t.Errorf("empty projects dir produced %d index entries", n)
+	}
+}
+
+func TestPreflightToleratesMissingProjects(t *testing.T) {
+	// Fresh machine: no projects directory yet. Startup succeeds and
+	// waits for it to appear.
+	s := newTestStorage(t, t.TempDir())
+	s.mu.RLock()
+	n := 0
+	for id := range s.fileIndex {
+		if id != "_history" {
+			n++
+		}
+	}
+	s.mu.RUnlock()
+	if n != 0 {
+		t.Errorf("missing projects dir produced %d index entries", n)
+	}
+}
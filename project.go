@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// encodeProjectPath mirrors how Claude names project directories: every
+// character outside [a-zA-Z0-9] in the project's absolute path becomes
+// a dash, so /root/module is stored under -root-module.
+func encodeProjectPath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// projectNode is one directory in the /_projects/tree response. Count
+// totals the conversations in the whole subtree, so a UI can show
+// badges without walking children.
+type projectNode struct {
+	Name     string                  `json:"name"`
+	Display  string                  `json:"display,omitempty"`
+	Count    int                     `json:"count"`
+	Children map[string]*projectNode `json:"children,omitempty"`
+}
+
+// decodeProjectSegment undoes encodeProjectPath for display as far as
+// possible: dashes stand for any non-alphanumeric character, so slashes
+// are the best guess for a path-shaped segment. The encoded name stays
+// authoritative in Name.
+func decodeProjectSegment(seg string) string {
+	if !strings.HasPrefix(seg, "-") {
+		return seg
+	}
+	return strings.ReplaceAll(seg, "-", "/")
+}
+
+// handleProjectsTree renders the directory structure under projectsDir
+// as a nested tree with conversation counts, built from the index
+// rather than a fresh walk. The response uses the shared list envelope:
+// items holds the top-level project nodes sorted by name, and total is
+// the conversation count across the whole tree. Trees aren't paginated,
+// so next_cursor stays empty.
+//
+// GET /_projects/tree
+func (s *ClaudeStorage) handleProjectsTree(w http.ResponseWriter, r *http.Request) {
+	root := &projectNode{Name: "projects"}
+
+	s.mu.RLock()
+	for id, path := range s.fileIndex {
+		if id == "_history" {
+			continue
+		}
+		rel, err := filepath.Rel(s.projectsDir, filepath.Dir(path))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue // extra files live outside the tree
+		}
+		root.Count++
+		node := root
+		if rel == "." {
+			continue
+		}
+		for _, seg := range strings.Split(rel, string(filepath.Separator)) {
+			if node.Children == nil {
+				node.Children = make(map[string]*projectNode)
+			}
+			child, ok := node.Children[seg]
+			if !ok {
+				child = &projectNode{Name: seg, Display: decodeProjectSegment(seg)}
+				node.Children[seg] = child
+			}
+			child.Count++
+			node = child
+		}
+	}
+	s.mu.RUnlock()
+
+	items := make([]*projectNode, 0, len(root.Children))
+	for _, child := range root.Children {
+		items = append(items, child)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pageEnvelope{
+		Items: items,
+		Total: root.Count,
+	})
+}
+
+// handleProject addresses a conversation by project and recency instead
+// of UUID: /p/<project>/<n> redirects to the nth most recent
+// conversation in that project (0 = newest). The project may be given
+// either as its decoded path (/root/module) or as the encoded directory
+// name (-root-module).
+//
+// GET /p/{project}/{n}
+func (s *ClaudeStorage) handleProject(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/p/")
+	slash := strings.LastIndex(rest, "/")
+	if slash < 0 {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "want /p/{project}/{n}")
+		return
+	}
+	project, nStr := rest[:slash], rest[slash+1:]
+	n, err := strconv.Atoi(nStr)
+	if err != nil || n < 0 {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "invalid index")
+		return
+	}
+	encoded := encodeProjectPath(project)
+
+	type conv struct {
+		id      string
+		modTime int64
+	}
+	var convs []conv
+	s.mu.RLock()
+	for id, path := range s.fileIndex {
+		dir := filepath.Base(filepath.Dir(path))
+		if dir != project && dir != encoded {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		convs = append(convs, conv{id, info.ModTime().UnixNano()})
+	}
+	s.mu.RUnlock()
+
+	if len(convs) == 0 {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "no conversations for project")
+		return
+	}
+	sort.Slice(convs, func(i, j int) bool { return convs[i].modTime > convs[j].modTime })
+	if n >= len(convs) {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "index out of range: project has "+strconv.Itoa(len(convs))+" conversation(s)")
+		return
+	}
+
+	target := "/" + convs[n].id
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
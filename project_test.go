@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProjectIndexAddressing(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	oldID, newID := testUUID(1), testUUID(2)
+	oldPath := writeStream(t, projectDir, oldID, userEntry(testUUID(10), "older"))
+	newPath := writeStream(t, projectDir, newID, userEntry(testUUID(11), "newer"))
+	base := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(oldPath, base, base); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(newPath, base.Add(time.Minute), base.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStorage(t, claudeDir)
+
+	// Index 0 is the most recent conversation in the project.
+	r := httptest.NewRequest("GET", "/p/-test-project/0", nil)
+	w := httptest.NewRecorder()
+	s.handleProject(w, r)
+	if w.Code != 302 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/"+newID {
+		t.Errorf("index 0 redirects to %s, want /%s", loc, newID)
+	}
+
+	r = httptest.NewRequest("GET", "/p/-test-project/1", nil)
+	w = httptest.NewRecorder()
+	s.handleProject(w, r)
+	if loc := w.Header().Get("Location"); loc != "/"+oldID {
+		t.Errorf("index 1 redirects to %s, want /%s", loc, oldID)
+	}
+}
+
+func TestProjectIndexOutOfRange(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	writeStream(t, projectDir, testUUID(1), userEntry(testUUID(10), "only one"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/p/-test-project/5", nil)
+	w := httptest.NewRecorder()
+	s.handleProject(w, r)
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404 for out-of-range index", w.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/p/-no-such-project/0", nil)
+	w = httptest.NewRecorder()
+	s.handleProject(w, r)
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404 for unknown project", w.Code)
+	}
+}
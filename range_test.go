@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+// The Stream-Content-Range header must describe exactly the bytes a read
+// returned: start at the resolved offset, end just past the last message,
+// total at the stream size.
+
+func TestReadRangeMatchesBoundaries(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	first := userEntry(testUUID(2), "first")
+	second := assistantEntry(testUUID(3), "test-model", "second")
+	writeStream(t, projectDir, id, first, second)
+	s := newTestStorage(t, claudeDir)
+
+	start := int64(len(first) + 1)
+	total := start + int64(len(second)+1)
+
+	var rng readRange
+	ctx := withReadRange(context.Background(), &rng)
+	result, err := s.Read(ctx, id, offsetFromInt(start), maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(result.Messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(result.Messages))
+	}
+
+	if !rng.set {
+		t.Fatal("read range was not reported")
+	}
+	if rng.start != start {
+		t.Errorf("range start = %d, want %d", rng.start, start)
+	}
+	if rng.end != offsetToInt(result.NextOffset) {
+		t.Errorf("range end = %d, want next offset %d", rng.end, offsetToInt(result.NextOffset))
+	}
+	if rng.total != total {
+		t.Errorf("range total = %d, want %d", rng.total, total)
+	}
+}
+
+func TestRangeHeaderWriter(t *testing.T) {
+	rng := &readRange{start: 10, end: 42, total: 100, set: true}
+	rec := httptest.NewRecorder()
+	w := &rangeHeaderWriter{ResponseWriter: rec, rng: rng}
+	w.Write([]byte("body"))
+
+	want := fmt.Sprintf("bytes %d-%d/%d", rng.start, rng.end, rng.total)
+	if got := rec.Header().Get("Stream-Content-Range"); got != want {
+		t.Errorf("Stream-Content-Range = %q, want %q", got, want)
+	}
+}
+
+// Unset ranges (endpoints that never read) must not emit the header.
+func TestRangeHeaderWriterUnset(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &rangeHeaderWriter{ResponseWriter: rec, rng: &readRange{}}
+	w.Write([]byte("body"))
+	if got := rec.Header().Get("Stream-Content-Range"); got != "" {
+		t.Errorf("Stream-Content-Range = %q, want unset", got)
+	}
+}
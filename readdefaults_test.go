@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseReadLimitsArrayDefault(t *testing.T) {
+	const arrayDefault = 256 << 10
+	tests := []struct {
+		name      string
+		url       string
+		wantBytes int
+		wantErr   bool
+	}{
+		// Non-live reads render one JSON array and get the smaller default.
+		{"plain array read", "/" + testUUID(1), arrayDefault, false},
+		// Live modes keep the handler's own page size.
+		{"sse read", "/" + testUUID(1) + "?live=sse", 0, false},
+		{"long-poll read", "/" + testUUID(1) + "?live=long-poll", 0, false},
+		// A client-specified limit is authoritative in both modes.
+		{"explicit limit, array", "/" + testUUID(1) + "?limit=42", 42, false},
+		{"explicit limit, live", "/" + testUUID(1) + "?live=sse&limit=42", 42, false},
+		{"bad limit", "/" + testUUID(1) + "?limit=banana", 0, true},
+		{"zero limit", "/" + testUUID(1) + "?limit=0", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := parseReadLimits(httptest.NewRequest("GET", tt.url, nil), arrayDefault)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseReadLimits(%s) accepted a bad limit", tt.url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseReadLimits(%s): %v", tt.url, err)
+			}
+			if rl.bytes != tt.wantBytes {
+				t.Errorf("parseReadLimits(%s).bytes = %d, want %d", tt.url, rl.bytes, tt.wantBytes)
+			}
+		})
+	}
+
+	// max_messages and skip parse alongside the default.
+	rl, err := parseReadLimits(httptest.NewRequest("GET", "/"+testUUID(1)+"?max_messages=5&skip=2", nil), arrayDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.messages != 5 || rl.skip != 2 || rl.bytes != arrayDefault {
+		t.Errorf("parseReadLimits = %+v, want messages 5, skip 2, bytes %d", rl, arrayDefault)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadinessGate(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	writeStream(t, projectDir, testUUID(1), userEntry(testUUID(2), "hello"))
+	s := newTestStorage(t, claudeDir)
+
+	// Synchronous construction completes the initial index, so the
+	// probe reports ready immediately.
+	r := httptest.NewRequest("GET", "/_healthz", nil)
+	w := httptest.NewRecorder()
+	s.handleHealthz(w, r)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "ready") {
+		t.Fatalf("after indexing: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	// Wind the gate back to the warming state a concurrent initial index
+	// would leave it in.
+	s.ready.Store(false)
+	w = httptest.NewRecorder()
+	s.handleHealthz(w, r)
+	if w.Code != 503 || !strings.Contains(w.Body.String(), "warming") {
+		t.Errorf("while warming: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	// Listings still answer during warm-up but are flagged as possibly
+	// incomplete.
+	w = httptest.NewRecorder()
+	s.handleListStreams(w, httptest.NewRequest("GET", "/_streams", nil))
+	if w.Code != 200 {
+		t.Fatalf("listing while warming: status %d", w.Code)
+	}
+	if w.Header().Get("X-Index-Warming") != "true" {
+		t.Error("warming listing missing X-Index-Warming header")
+	}
+
+	s.ready.Store(true)
+	w = httptest.NewRecorder()
+	s.handleListStreams(w, httptest.NewRequest("GET", "/_streams", nil))
+	if w.Header().Get("X-Index-Warming") != "" {
+		t.Error("ready listing still flagged as warming")
+	}
+}
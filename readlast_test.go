@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadLastLines(t *testing.T) {
+	_, projectDir := newTestClaudeDir(t)
+
+	for _, tc := range []struct {
+		name  string
+		total int
+		n     int
+	}{
+		{"smaller than one chunk", 5, 2},
+		{"n exceeds file", 3, 10},
+		{"single line", 1, 1},
+		{"spans multiple chunks", 3000, 7}, // ~60 bytes/line ≫ tailChunkSize
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := make([]string, tc.total)
+			var end int64
+			for i := range lines {
+				lines[i] = userEntry(testUUID(1), fmt.Sprintf("line number %06d padded out for size", i))
+				end += int64(len(lines[i]) + 1)
+			}
+			path := writeStream(t, projectDir, strings.ReplaceAll(tc.name, " ", "-"), lines...)
+
+			got, err := readLastLines(path, tc.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tc.n
+			if want > tc.total {
+				want = tc.total
+			}
+			if len(got) != want {
+				t.Fatalf("got %d lines, want %d", len(got), want)
+			}
+			// Each message matches the file's tail with its absolute
+			// end-of-line offset.
+			off := end
+			for i := len(got) - 1; i >= 0; i-- {
+				wantLine := lines[tc.total-(len(got)-i)]
+				if string(got[i].Data) != wantLine {
+					t.Errorf("line %d = %q, want %q", i, got[i].Data, wantLine)
+				}
+				if gotOff := offsetToInt(got[i].Offset); gotOff != off {
+					t.Errorf("line %d offset = %d, want %d", i, gotOff, off)
+				}
+				off -= int64(len(wantLine) + 1)
+			}
+		})
+	}
+}
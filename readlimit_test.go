@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestReadLimitDefaultsAndCeiling(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	total := 10
+	lines := make([]string, total)
+	for i := range lines {
+		lines[i] = userEntry(testUUID(100+i), "a line of payload")
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+	ctx := context.Background()
+
+	// With no client-requested limit, the handler-provided default page
+	// size governs: a few lines' worth yields a partial page.
+	pageBytes := 3 * (len(lines[0]) + 1)
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, pageBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) == 0 || len(result.Messages) >= total {
+		t.Errorf("default-limited read returned %d of %d messages, want a partial page", len(result.Messages), total)
+	}
+
+	// A client limit far above the ceiling is clamped to it.
+	oldMax := maxReadLimitBytes
+	maxReadLimitBytes = pageBytes
+	t.Cleanup(func() { maxReadLimitBytes = oldMax })
+
+	huge := withReadLimits(ctx, &readLimits{bytes: 1 << 30})
+	result, err = s.Read(huge, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) >= total {
+		t.Errorf("oversized client limit was not clamped: got all %d messages", len(result.Messages))
+	}
+}
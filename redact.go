@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// redactPatterns are the compiled -redact-patterns regexes. Empty unless
+// the flag was given; with no patterns, ?redact=1 is a no-op.
+var redactPatterns []*regexp.Regexp
+
+// redactMask replaces every matched span in redacted output.
+const redactMask = "[REDACTED]"
+
+// loadRedactPatterns reads one regex per line from the given file.
+// Blank lines and #-comments are skipped; any pattern failing to
+// compile fails startup, since silently serving unredacted output would
+// defeat the point.
+func loadRedactPatterns(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", line, err)
+		}
+		redactPatterns = append(redactPatterns, re)
+	}
+	return scanner.Err()
+}
+
+// redactText masks every pattern match in s. Patterns apply in order,
+// each over the output of the previous one, so overlapping matches
+// collapse into a single mask rather than leaking fragments.
+func redactText(s string) string {
+	for _, re := range redactPatterns {
+		s = re.ReplaceAllString(s, redactMask)
+	}
+	return s
+}
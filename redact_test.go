@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactedExport(t *testing.T) {
+	old := redactPatterns
+	redactPatterns = nil
+	t.Cleanup(func() { redactPatterns = old })
+
+	patFile := filepath.Join(t.TempDir(), "patterns.txt")
+	if err := os.WriteFile(patFile, []byte("# fake API keys\nsk-[a-z0-9]+\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadRedactPatterns(patFile); err != nil {
+		t.Fatalf("load patterns: %v", err)
+	}
+
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "my key is sk-abc123 please keep it"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_export/"+id+"?redact=1", nil)
+	w := httptest.NewRecorder()
+	s.handleExport(w, r)
+
+	body := w.Body.String()
+	if strings.Contains(body, "sk-abc123") {
+		t.Errorf("secret leaked in redacted export:\n%s", body)
+	}
+	if !strings.Contains(body, redactMask) {
+		t.Errorf("mask missing from redacted export:\n%s", body)
+	}
+
+	// Without ?redact=1 the raw content passes through untouched.
+	r = httptest.NewRequest("GET", "/_export/"+id, nil)
+	w = httptest.NewRecorder()
+	s.handleExport(w, r)
+	if !strings.Contains(w.Body.String(), "sk-abc123") {
+		t.Errorf("unredacted export altered content:\n%s", w.Body.String())
+	}
+}
+
+func TestLoadRedactPatternsRejectsBadRegex(t *testing.T) {
+	old := redactPatterns
+	t.Cleanup(func() { redactPatterns = old })
+
+	patFile := filepath.Join(t.TempDir(), "patterns.txt")
+	if err := os.WriteFile(patFile, []byte("([unclosed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadRedactPatterns(patFile); err == nil {
+		t.Errorf("bad pattern accepted; unredacted output could be served silently")
+	}
+}
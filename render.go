@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Shared text/markdown rendering of a conversation. Both formats walk
+// the same entries; they only differ in how a turn is framed.
+
+// renderOptions controls the shared renderer.
+type renderOptions struct {
+	format   string       // "text", "markdown" or "html"
+	thinking thinkingMode // rendered output excludes thinking by default
+	numbers  bool         // prefix each turn with its original message index
+	redact   bool         // mask -redact-patterns matches in the output
+	flush    func()       // called periodically so huge exports stream progressively
+}
+
+// renderFlushEvery is how many rendered turns pass between flushes.
+const renderFlushEvery = 32
+
+// renderConversation writes a conversation as plain text or markdown.
+// Turn numbers count every message entry in the file, not just rendered
+// ones, so ?numbers=1 output stays comparable across filtered views.
+func renderConversation(w io.Writer, f io.Reader, opts renderOptions) error {
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	index := 0
+	rendered := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var entry struct {
+			Type    string `json:"type"`
+			Message struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		index++
+
+		role := entry.Message.Role
+		if role == "" {
+			role = entry.Type
+		}
+		if role != "user" && role != "assistant" {
+			continue
+		}
+
+		text := contentText(extractMessageContent(filterThinking(line, opts.thinking)))
+		if text == "" {
+			continue
+		}
+		// Redaction applies to extracted text only; the raw JSONL and its
+		// offsets are never rewritten.
+		if opts.redact {
+			text = redactText(text)
+		}
+
+		num := ""
+		if opts.numbers {
+			num = fmt.Sprintf("[%d] ", index)
+		}
+
+		title := strings.ToUpper(role[:1]) + role[1:]
+		switch opts.format {
+		case "markdown":
+			fmt.Fprintf(w, "## %s%s\n\n%s\n\n", num, title, text)
+		case "html":
+			fmt.Fprintf(w, "<div class=%q><h2>%s%s</h2><pre>%s</pre></div>\n",
+				"turn "+role, num, title, html.EscapeString(text))
+		default:
+			fmt.Fprintf(w, "%s%s: %s\n\n", num, role, text)
+		}
+
+		// Turns are written straight to the response; periodic flushes
+		// keep the client seeing progress instead of one giant buffer.
+		rendered++
+		if opts.flush != nil && rendered%renderFlushEvery == 0 {
+			opts.flush()
+		}
+	}
+	return scanner.Err()
+}
+
+// extractMessageContent pulls the raw message.content out of a JSONL
+// entry, or nil if the line has none.
+func extractMessageContent(line []byte) json.RawMessage {
+	var entry struct {
+		Message struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil
+	}
+	return entry.Message.Content
+}
+
+// handleExport renders a conversation as plain text or markdown for
+// sharing outside the viewer.
+//
+// GET /_export/{streamID}?format=markdown[&thinking=include][&numbers=1]
+func (s *ClaudeStorage) handleExport(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_export/")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "text"
+	}
+	if format != "text" && format != "markdown" && format != "html" {
+		apiError(w, http.StatusBadRequest, codeBadRequest, "invalid format (want text, markdown or html)")
+		return
+	}
+	mode, err := parseThinkingMode(r.URL.Query().Get("thinking"), thinkingExclude)
+	if err != nil {
+		apiError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	path, err := s.getPathCtx(r.Context(), streamID)
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	switch format {
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	flusher, _ := w.(http.Flusher)
+	opts := renderOptions{
+		format:   format,
+		thinking: mode,
+		numbers:  r.URL.Query().Get("numbers") == "1",
+		redact:   r.URL.Query().Get("redact") == "1",
+	}
+	if flusher != nil {
+		opts.flush = flusher.Flush
+	}
+
+	// The HTML wrapper brackets the streamed body; the closing tags only
+	// go out once rendering finished cleanly.
+	if format == "html" {
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>%s</title></head>\n<body>\n",
+			html.EscapeString(streamID))
+	}
+	if err := renderConversation(w, f, opts); err != nil {
+		return // mid-stream; nothing sensible to add
+	}
+	if format == "html" {
+		io.WriteString(w, "</body>\n</html>\n")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExportNumbering(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id,
+		userEntry(testUUID(2), "first turn"),
+		toolUseEntry(testUUID(3), "Bash"),
+		assistantEntry(testUUID(4), "test-model", "third turn"),
+	)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_export/"+id+"?numbers=1", nil)
+	w := httptest.NewRecorder()
+	s.handleExport(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "[1] user: first turn") {
+		t.Errorf("body missing numbered first turn:\n%s", body)
+	}
+	// The tool entry renders nothing but still consumes index 2, so
+	// numbering matches original positions across filtered views.
+	if !strings.Contains(body, "[3] assistant: third turn") {
+		t.Errorf("body missing [3] for the assistant turn:\n%s", body)
+	}
+	if strings.Contains(body, "[2]") {
+		t.Errorf("tool-only turn should not render:\n%s", body)
+	}
+
+	// Without the flag no numbering appears.
+	r = httptest.NewRequest("GET", "/_export/"+id, nil)
+	w = httptest.NewRecorder()
+	s.handleExport(w, r)
+	if strings.Contains(w.Body.String(), "[1]") {
+		t.Errorf("unnumbered export contains indices:\n%s", w.Body.String())
+	}
+}
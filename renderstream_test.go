@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderStreamsLargeExports(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	total := 3 * renderFlushEvery
+	lines := make([]string, total)
+	for i := range lines {
+		lines[i] = userEntry(testUUID(100+i), "turn after turn of content")
+	}
+	path := writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	// The renderer flushes periodically instead of buffering the whole
+	// document: three windows' worth of turns means at least two
+	// mid-render flushes.
+	f, err := openStream(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	flushes := 0
+	var out strings.Builder
+	err = renderConversation(&out, f, renderOptions{
+		format: "markdown",
+		flush:  func() { flushes++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flushes < 2 {
+		t.Errorf("flushes = %d, want periodic flushing during a large render", flushes)
+	}
+	if got := strings.Count(out.String(), "## User"); got != total {
+		t.Errorf("rendered %d turns, want %d", got, total)
+	}
+
+	// The HTML wrapper brackets the streamed body correctly.
+	r := httptest.NewRequest("GET", "/_export/"+id+"?format=html", nil)
+	w := httptest.NewRecorder()
+	s.handleExport(w, r)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %.200s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "<!DOCTYPE html>") {
+		t.Errorf("missing opening wrapper:\n%.100s", body)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(body), "</html>") {
+		t.Errorf("missing closing wrapper:\n...%s", body[len(body)-100:])
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxReplayDelay clamps the pause between replayed messages so long
+// idle gaps in the original session don't stall a demo.
+const maxReplayDelay = 5 * time.Second
+
+// handleReplay streams a conversation over SSE paced by the original
+// inter-message timestamps, scaled by ?rate= (2.0 plays back twice as
+// fast). Messages without timestamps are sent immediately.
+//
+// GET /_replay/{streamID}?rate=1.0
+func (s *ClaudeStorage) handleReplay(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_replay/")
+
+	rate := 1.0
+	if v := r.URL.Query().Get("rate"); v != "" {
+		var err error
+		rate, err = strconv.ParseFloat(v, 64)
+		if err != nil || rate <= 0 {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid rate")
+			return
+		}
+	}
+
+	path, err := s.getPathCtx(r.Context(), streamID)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher, _ := w.(http.Flusher)
+
+	ctx := r.Context()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	var prev time.Time
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !json.Valid(line) {
+			continue
+		}
+
+		var entry struct {
+			Timestamp string `json:"timestamp"`
+		}
+		json.Unmarshal(line, &entry)
+		if ts, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+			if !prev.IsZero() && ts.After(prev) {
+				delay := time.Duration(float64(ts.Sub(prev)) / rate)
+				if delay > maxReplayDelay {
+					delay = maxReplayDelay
+				}
+				timer.Reset(delay)
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.shutdownCh:
+					io.WriteString(w, "event: shutdown\ndata: {\"reason\":\"server shutting down\"}\n\n")
+					if flusher != nil {
+						flusher.Flush()
+					}
+					return
+				case <-timer.C:
+				}
+			}
+			prev = ts
+		}
+
+		io.WriteString(w, "event: message\ndata: ")
+		w.Write(line)
+		io.WriteString(w, "\n\n")
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayEmitsAllMessages(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	first := timedEntry(testUUID(2), base, "opening")
+	second := timedEntry(testUUID(3), base.Add(2*time.Second), "two seconds later")
+	writeStream(t, projectDir, id, first, second)
+	s := newTestStorage(t, claudeDir)
+
+	// A huge rate collapses the 2s gap so the test finishes instantly.
+	start := time.Now()
+	r := httptest.NewRequest("GET", "/_replay/"+id+"?rate=100000", nil)
+	w := httptest.NewRecorder()
+	s.handleReplay(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type = %s", ct)
+	}
+	body := w.Body.String()
+	for _, line := range []string{first, second} {
+		if !strings.Contains(body, "data: "+line) {
+			t.Errorf("replay missing line %s:\n%s", line, body)
+		}
+	}
+	if got := strings.Count(body, "event: message"); got != 2 {
+		t.Errorf("got %d message events, want 2", got)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("replay at rate=100000 took %v; gap not scaled", elapsed)
+	}
+}
+
+func TestReplayInvalidRate(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "hi"))
+	s := newTestStorage(t, claudeDir)
+
+	for _, rate := range []string{"0", "-1", "fast"} {
+		r := httptest.NewRequest("GET", fmt.Sprintf("/_replay/%s?rate=%s", id, rate), nil)
+		w := httptest.NewRecorder()
+		s.handleReplay(w, r)
+		if w.Code != 400 {
+			t.Errorf("rate=%s: status = %d, want 400", rate, w.Code)
+		}
+	}
+}
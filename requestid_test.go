@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDEchoed(t *testing.T) {
+	var seen string
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestIDFrom(r.Context())
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-ID", "client-chose-this")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if got := w.Header().Get("X-Request-ID"); got != "client-chose-this" {
+		t.Errorf("echoed ID = %q, want the client's", got)
+	}
+	if seen != "client-chose-this" {
+		t.Errorf("context ID = %q, want the client's", seen)
+	}
+
+	// Without an incoming ID one is generated and still echoed.
+	r = httptest.NewRequest("GET", "/", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if got := w.Header().Get("X-Request-ID"); got == "" {
+		t.Errorf("no generated ID echoed")
+	} else if got != seen {
+		t.Errorf("echoed %q but context carried %q", got, seen)
+	}
+}
+
+func TestRequestIDInAuditLine(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "traced"))
+	s := newTestStorage(t, claudeDir)
+
+	logPath := t.TempDir() + "/audit.jsonl"
+	audit, err := newAuditLogger(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.audit = audit
+
+	ctx := withRequestID(context.Background(), "req-42")
+	s.auditRead(ctx, id, "0", 10)
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"requestId":"req-42"`) {
+		t.Errorf("audit line missing request ID: %s", data)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestReadOverTailSignalsReset(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := userEntry(testUUID(2), "short file")
+	writeStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+
+	size := int64(len(line) + 1)
+	var reset bool
+	ctx := withResetSignal(context.Background(), &reset)
+
+	result, err := s.Read(ctx, id, offsetFromInt(size+1000), maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("over-tail read should not error: %v", err)
+	}
+	if len(result.Messages) != 0 {
+		t.Errorf("got %d messages, want an empty page", len(result.Messages))
+	}
+	if got := offsetToInt(result.NextOffset); got != size {
+		t.Errorf("next offset = %d, want the current tail %d", got, size)
+	}
+	if !reset {
+		t.Errorf("reset signal not set for an over-tail offset")
+	}
+
+	// An in-range offset must not trip the signal.
+	reset = false
+	if _, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes); err != nil {
+		t.Fatal(err)
+	}
+	if reset {
+		t.Errorf("reset signal set for a valid offset")
+	}
+}
+
+func TestResetHeaderWriter(t *testing.T) {
+	// The header lands only when the flag was set before the first byte.
+	for _, set := range []bool{true, false} {
+		rec := httptest.NewRecorder()
+		flag := set
+		rw := &resetHeaderWriter{ResponseWriter: rec, flag: &flag}
+		rw.Write([]byte("{}"))
+		got := rec.Header().Get("Stream-Reset-Required")
+		if set && got != "true" {
+			t.Errorf("flag set: header = %q, want true", got)
+		}
+		if !set && got != "" {
+			t.Errorf("flag unset: header = %q, want absent", got)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestResolveLiveRecoversFromMovedFile(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := userEntry(testUUID(2), "follow me")
+	oldPath := writeStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := context.Background()
+	if _, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rename the project out from under the index, then force the stale
+	// entry back in case a watch event already repaired it — the read
+	// must survive on resolveLive's ENOENT recovery alone.
+	newProject := filepath.Join(claudeDir, "projects", "-renamed-project")
+	if err := os.MkdirAll(newProject, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(oldPath, filepath.Join(newProject, id+".jsonl")); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.fileIndex[id] = oldPath
+	s.mu.Unlock()
+
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("read after move: %v", err)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0].Data) != line {
+		t.Fatalf("read after move = %+v, want the original line", result.Messages)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// handleResume returns the CLI command that resumes a conversation,
+// bridging the viewer back to the terminal. The working directory is
+// taken from the first entry carrying a cwd field; conversations without
+// one just get the bare resume command.
+//
+// GET /_resume/{streamID}
+func (s *ClaudeStorage) handleResume(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_resume/")
+
+	path, err := s.getPathCtx(r.Context(), streamID)
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+
+	f, err := openStream(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "open stream")
+		return
+	}
+	defer f.Close()
+
+	cwd := ""
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			Cwd string `json:"cwd"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.Cwd != "" {
+			cwd = entry.Cwd
+			break
+		}
+	}
+
+	command := "claude --resume " + streamID
+	if cwd != "" {
+		command = "cd " + cwd + " && " + command
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		SessionID string `json:"sessionId"`
+		Cwd       string `json:"cwd,omitempty"`
+		Command   string `json:"command"`
+	}{streamID, cwd, command})
+}
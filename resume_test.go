@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResumeCommand(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := fmt.Sprintf(`{"type":"user","uuid":%q,"cwd":"/home/u/proj","message":{"role":"user","content":"hi"}}`, testUUID(2))
+	writeStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_resume/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleResume(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		SessionID string `json:"sessionId"`
+		Cwd       string `json:"cwd"`
+		Command   string `json:"command"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.SessionID != id || resp.Cwd != "/home/u/proj" {
+		t.Errorf("resume = %+v", resp)
+	}
+	if !strings.Contains(resp.Command, "--resume "+id) || !strings.Contains(resp.Command, "cd /home/u/proj") {
+		t.Errorf("command = %q, want the session ID and cwd", resp.Command)
+	}
+}
+
+func TestResumeCommandNoCwd(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "no cwd here"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_resume/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleResume(w, r)
+	var resp struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(resp.Command, "cd ") {
+		t.Errorf("command = %q, want no cd without a recorded cwd", resp.Command)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestSameSizeRewriteNotifiesSubscribers(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	// Hand-built lines of identical length so the rewrite preserves the
+	// file size exactly — only the fingerprint can tell them apart.
+	lineA := `{"uuid":"` + testUUID(2) + `","type":"user","message":{"role":"user","content":"version A"}}`
+	lineB := strings.Replace(lineA, "version A", "version B", 1)
+	path := writeStream(t, projectDir, id, lineA)
+	s := newTestStorage(t, claudeDir)
+
+	ch, err := s.Subscribe(context.Background(), id, durablestream.ZeroOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// First post-start write records the file's fingerprint.
+	if err := os.WriteFile(path, []byte(lineA+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification for the initial write")
+	}
+
+	// Let the mod time tick past the recorded fingerprint, then rewrite
+	// in place to the same byte count.
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(path, []byte(lineB+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification for the same-size rewrite")
+	}
+
+	// The rewrite also invalidated derived state: a read sees the new
+	// content once the settle window passes.
+	result, err := s.Read(context.Background(), id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0].Data) != lineB {
+		t.Fatalf("read after rewrite = %+v, want the rewritten line", result.Messages)
+	}
+}
+
+func TestReadWaitsOutRewriteSettleWindow(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "old content"))
+	s := newTestStorage(t, claudeDir)
+
+	// Hold the rewrite lock the way a detected rewrite's settle window
+	// does, and rewrite the file underneath the blocked read.
+	lk := s.rewriteLock(path)
+	lk.Lock()
+
+	done := make(chan *durablestream.ReadResult, 1)
+	go func() {
+		result, err := s.Read(context.Background(), id, durablestream.ZeroOffset, maxReadLimitBytes)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- result
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("read completed while the rewrite was still settling")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	newLine := userEntry(testUUID(3), "new content")
+	if err := os.WriteFile(path, []byte(newLine+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lk.Unlock()
+
+	select {
+	case result := <-done:
+		if result == nil {
+			t.Fatal("read failed")
+		}
+		if len(result.Messages) != 1 || string(result.Messages[0].Data) != newLine {
+			t.Fatalf("read = %+v, want the settled rewrite content", result.Messages)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("read never completed after the settle window released")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScanTimeoutReturnsPartialWithFlag(t *testing.T) {
+	old := scanTimeout
+	scanTimeout = time.Nanosecond
+	t.Cleanup(func() { scanTimeout = old })
+
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	total := 4 * ctxCheckEvery
+	lines := make([]string, total)
+	for i := range lines {
+		lines[i] = userEntry(testUUID(100+i), "a line the deadline cuts short")
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_outline/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleOutline(w, r)
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Outline   []json.RawMessage `json:"outline"`
+		Truncated bool              `json:"truncated"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Truncated {
+		t.Fatal("expired scan deadline did not set truncated")
+	}
+	if len(resp.Outline) >= total {
+		t.Errorf("got all %d entries despite the deadline", len(resp.Outline))
+	}
+}
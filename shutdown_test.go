@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownClosesFollowers(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "following along"))
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_tail/"+id+"?n=1&follow=1", nil)
+	w := &lockedRecorder{rec: httptest.NewRecorder()}
+	done := make(chan struct{})
+	go func() {
+		s.handleTail(w, r)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.RLock()
+		n := len(s.subscribers[id])
+		s.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("follower never subscribed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The follower's request context stays live; only the shutdown
+	// broadcast can end the stream before graceful Shutdown times out.
+	s.announceShutdown()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("follower did not close after the shutdown broadcast")
+	}
+	if !strings.Contains(w.body(), "_shutdown") {
+		t.Errorf("no final shutdown event in body:\n%s", w.body())
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// sidecarMeta is the optional user-editable annotation file next to a
+// conversation (`<uuid>.meta.json` beside `<uuid>.jsonl`). It lets users
+// override what the server reports without touching the JSONL itself.
+type sidecarMeta struct {
+	ContentType string `json:"contentType"`
+	Title       string `json:"title"`
+}
+
+// sidecarPath returns the sidecar path for a conversation file.
+func sidecarPath(path string) string {
+	return strings.TrimSuffix(path, ".jsonl") + ".meta.json"
+}
+
+// readSidecar loads the sidecar for a conversation file. Absent or
+// malformed sidecars yield nil so callers fall back to defaults.
+func readSidecar(path string) *sidecarMeta {
+	data, err := os.ReadFile(sidecarPath(path))
+	if err != nil {
+		return nil
+	}
+	var meta sidecarMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil
+	}
+	return &meta
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSidecarContentTypeOverride(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "hello"))
+	s := newTestStorage(t, claudeDir)
+
+	info, err := s.Head(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	if info.ContentType != "application/json" {
+		t.Errorf("default content type = %s", info.ContentType)
+	}
+
+	sidecar := filepath.Join(projectDir, id+".meta.json")
+	if err := os.WriteFile(sidecar, []byte(`{"contentType":"application/x-ndjson"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err = s.Head(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ContentType != "application/x-ndjson" {
+		t.Errorf("content type = %s, want the sidecar override", info.ContentType)
+	}
+}
+
+func TestSidecarTitleOverride(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "original first words"))
+	s := newTestStorage(t, claudeDir)
+
+	sidecar := filepath.Join(projectDir, id+".meta.json")
+	if err := os.WriteFile(sidecar, []byte(`{"title":"Curated title"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := s.metadata(id)
+	if err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if meta.Title != "Curated title" {
+		t.Errorf("title = %q, want the sidecar override", meta.Title)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTopLevelOnlyHidesSidechains(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	mainID, sideID := testUUID(1), testUUID(2)
+	writeStream(t, projectDir, mainID, userEntry(testUUID(10), "the real conversation"))
+	sideLine := fmt.Sprintf(`{"type":"user","uuid":%q,"isSidechain":true,"message":{"role":"user","content":"subtask chatter"}}`, testUUID(11))
+	writeStream(t, projectDir, sideID, sideLine)
+	s := newTestStorage(t, claudeDir)
+
+	list := func(url string) []string {
+		r := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		s.handleListStreams(w, r)
+		if w.Code != 200 {
+			t.Fatalf("%s: status = %d", url, w.Code)
+		}
+		var page struct {
+			Items []*streamMetadata `json:"items"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(page.Items))
+		for i, m := range page.Items {
+			ids[i] = m.ID
+		}
+		return ids
+	}
+
+	// Default listing shows both.
+	if ids := list("/_streams"); len(ids) != 2 {
+		t.Errorf("default listing = %v, want both streams", ids)
+	}
+
+	// top_level_only hides the sidechain but keeps it readable by ID.
+	ids := list("/_streams?top_level_only=1")
+	if len(ids) != 1 || ids[0] != mainID {
+		t.Errorf("top_level_only listing = %v, want only %s", ids, mainID)
+	}
+	if _, err := s.getPath(sideID); err != nil {
+		t.Errorf("sidechain unreadable by ID: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestSingleFileMode(t *testing.T) {
+	dir := t.TempDir()
+	id := testUUID(1)
+	first := userEntry(testUUID(2), "just this one file")
+	path := writeStream(t, dir, id, first)
+	s := newSingleFileStorage(t, path)
+
+	ctx := context.Background()
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0].Data) != first {
+		t.Fatalf("read = %+v, want the file's line", result.Messages)
+	}
+
+	// The file's directory is watched, so appends reach subscribers like
+	// any other stream.
+	ch, err := s.Subscribe(ctx, id, result.NextOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendLine(t, path, userEntry(testUUID(3), "tailed in"))
+	select {
+	case _, ok := <-ch:
+		if !ok {
+			t.Fatal("subscription closed early")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("append never reached the subscriber")
+	}
+
+	// No projects tree, no other streams: an unrelated ID doesn't
+	// resolve.
+	if _, err := s.Read(ctx, testUUID(9), durablestream.ZeroOffset, maxReadLimitBytes); err == nil {
+		t.Error("unknown ID resolved in single-file mode")
+	}
+}
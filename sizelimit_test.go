@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestMaxStreamBytesGuard(t *testing.T) {
+	old := maxStreamBytes
+	maxStreamBytes = 256
+	t.Cleanup(func() { maxStreamBytes = old })
+
+	claudeDir, projectDir := newTestClaudeDir(t)
+	smallID, bigID := testUUID(1), testUUID(2)
+	writeStream(t, projectDir, smallID, userEntry(testUUID(10), "fits"))
+	writeStream(t, projectDir, bigID, userEntry(testUUID(11), strings.Repeat("x", 500)))
+	s := newTestStorage(t, claudeDir)
+
+	// The oversized file is excluded from the index.
+	s.mu.RLock()
+	_, indexed := s.fileIndex[bigID]
+	s.mu.RUnlock()
+	if indexed {
+		t.Errorf("oversized stream was indexed")
+	}
+
+	ctx := context.Background()
+	if _, err := s.Read(ctx, smallID, durablestream.ZeroOffset, maxReadLimitBytes); err != nil {
+		t.Errorf("small stream read: %v", err)
+	}
+	if _, err := s.Read(ctx, bigID, durablestream.ZeroOffset, maxReadLimitBytes); !errors.Is(err, ErrStreamTooLarge) {
+		t.Errorf("oversized read err = %v, want ErrStreamTooLarge", err)
+	}
+
+	// ?force=1 overrides the guard for deliberate access.
+	result, err := s.Read(withForceRead(ctx), bigID, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("forced read: %v", err)
+	}
+	if len(result.Messages) != 1 {
+		t.Errorf("forced read returned %d messages", len(result.Messages))
+	}
+}
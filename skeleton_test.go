@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestSkeletonReadStripsContent(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	line := assistantEntry(testUUID(2), "test-model", "a long body that should vanish")
+	writeStream(t, projectDir, id, line)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := withSkeleton(context.Background())
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 {
+		t.Fatalf("got %d messages", len(result.Messages))
+	}
+
+	// The skeleton is a flat envelope: identity and threading fields
+	// only, no message body at all.
+	var entry struct {
+		UUID  string `json:"uuid"`
+		Type  string `json:"type"`
+		Role  string `json:"role"`
+		Model string `json:"model"`
+	}
+	data := result.Messages[0].Data
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("bad skeleton line %s: %v", data, err)
+	}
+	if entry.UUID != testUUID(2) || entry.Type != "assistant" || entry.Role != "assistant" || entry.Model != "test-model" {
+		t.Errorf("skeleton envelope = %s", data)
+	}
+	var full map[string]json.RawMessage
+	if err := json.Unmarshal(data, &full); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := full["message"]; ok {
+		t.Errorf("message body survived skeleton mode: %s", data)
+	}
+	if bytes.Contains(data, []byte("a long body")) {
+		t.Errorf("content text leaked: %s", data)
+	}
+
+	// Offsets still count the original line's bytes.
+	if got := offsetToInt(result.Messages[0].Offset); got != int64(len(line)+1) {
+		t.Errorf("offset = %d, want original %d", got, len(line)+1)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestNonJSONLFileRejected(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := filepath.Join(projectDir, id+".jsonl")
+	if err := os.WriteFile(path, []byte("\x7fELF\x02\x01 definitely not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStorage(t, claudeDir)
+
+	if _, err := s.Read(context.Background(), id, durablestream.ZeroOffset, maxReadLimitBytes); !errors.Is(err, ErrNotJSONL) {
+		t.Errorf("Read err = %v, want ErrNotJSONL", err)
+	}
+	if _, err := s.Head(context.Background(), id); !errors.Is(err, ErrNotJSONL) {
+		t.Errorf("Head err = %v, want ErrNotJSONL", err)
+	}
+}
+
+func TestEmptyJSONLFileAccepted(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := filepath.Join(projectDir, id+".jsonl")
+	// Brand-new conversations start as empty files; the sniff must not
+	// reject them.
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStorage(t, claudeDir)
+
+	result, err := s.Read(context.Background(), id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("empty file read: %v", err)
+	}
+	if len(result.Messages) != 0 {
+		t.Errorf("got %d messages from an empty file", len(result.Messages))
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// streamStats aggregates per-conversation statistics for /_stats.
+type streamStats struct {
+	ID             string         `json:"id"`
+	UserChars      int            `json:"userChars"`
+	AssistantChars int            `json:"assistantChars"`
+	MessagesByRole map[string]int `json:"messagesByRole"`
+	ToolCalls      int            `json:"toolCalls"`
+	// Duration is the wall-clock span from first to last timestamp.
+	Duration string `json:"duration,omitempty"`
+}
+
+// statsCache memoizes computed stats keyed by file path, invalidated on
+// size/mtime change like the other derived-data caches.
+type statsCache struct {
+	mu      sync.Mutex
+	entries map[string]statsCacheEntry
+}
+
+type statsCacheEntry struct {
+	size    int64
+	modTime time.Time
+	stats   streamStats
+}
+
+// handleStats returns aggregate statistics for one conversation: char
+// counts per side, message counts by role, tool calls and duration.
+//
+// GET /_stats/{streamID}
+func (s *ClaudeStorage) handleStats(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_stats/")
+
+	path, err := s.getPath(streamID)
+	if err != nil {
+		apiErrorFrom(w, err)
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "stat stream")
+		return
+	}
+
+	s.stats.mu.Lock()
+	if e, ok := s.stats.entries[path]; ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		s.stats.mu.Unlock()
+		stats := e.stats
+		stats.ID = streamID
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+		return
+	}
+	s.stats.mu.Unlock()
+
+	stats, err := computeStats(path)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "scan stream")
+		return
+	}
+	stats.ID = streamID
+
+	s.stats.mu.Lock()
+	s.stats.entries[path] = statsCacheEntry{size: info.Size(), modTime: info.ModTime(), stats: *stats}
+	s.stats.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func computeStats(path string) (*streamStats, error) {
+	f, err := openStream(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := &streamStats{MessagesByRole: make(map[string]int)}
+	var first, last time.Time
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var entry struct {
+			Type      string `json:"type"`
+			Timestamp string `json:"timestamp"`
+			Message   struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+
+		role := entry.Message.Role
+		if role == "" {
+			role = entry.Type
+		}
+		if role != "" {
+			stats.MessagesByRole[role]++
+		}
+
+		text := contentText(entry.Message.Content)
+		switch role {
+		case "user":
+			stats.UserChars += len(text)
+		case "assistant":
+			stats.AssistantChars += len(text)
+		}
+
+		if isTool, names, _ := toolActivity(line); isTool {
+			stats.ToolCalls += len(names)
+		}
+
+		if entry.Timestamp != "" {
+			if ts, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+				if first.IsZero() || ts.Before(first) {
+					first = ts
+				}
+				if ts.After(last) {
+					last = ts
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !first.IsZero() && !last.IsZero() {
+		stats.Duration = last.Sub(first).String()
+	}
+	return stats, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatsEndpoint(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id,
+		userEntry(testUUID(2), "how big"),
+		assistantEntry(testUUID(3), "test-model", "quite big"),
+		toolUseEntry(testUUID(4), "Bash"),
+	)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_stats/"+id, nil)
+	w := httptest.NewRecorder()
+	s.handleStats(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var stats streamStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.ID != id {
+		t.Errorf("id = %s, want %s", stats.ID, id)
+	}
+	if stats.UserChars != len("how big") {
+		t.Errorf("userChars = %d, want %d", stats.UserChars, len("how big"))
+	}
+	if stats.AssistantChars != len("quite big") {
+		t.Errorf("assistantChars = %d, want %d", stats.AssistantChars, len("quite big"))
+	}
+	if stats.ToolCalls != 1 {
+		t.Errorf("toolCalls = %d, want 1", stats.ToolCalls)
+	}
+	if stats.MessagesByRole["user"] != 1 {
+		t.Errorf("messagesByRole = %v", stats.MessagesByRole)
+	}
+}
@@ -2,26 +2,345 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/ahimsalabs/durable-streams-go/durablestream"
 	"github.com/fsnotify/fsnotify"
 )
 
+// useMmap selects the mmap read path for Read. Set by -mmap.
+var useMmap bool
+
+// subscribeBuffer is the notification channel depth per subscriber.
+// Set by -subscribe-buffer.
+var subscribeBuffer = 1
+
+// noHistory leaves the _history stream unregistered and unwatched, for
+// users who consider command history too sensitive to serve. Set by
+// -no-history.
+var noHistory bool
+
+// maxStreamBytes excludes streams larger than this from indexing and
+// reads, protecting against a runaway file being served by accident.
+// Set by -max-stream-bytes; 0 means no limit.
+var maxStreamBytes int64
+
 var (
-	ErrReadOnly       = errors.New("storage is read-only")
-	ErrStreamNotFound = errors.New("stream not found")
+	ErrReadOnly          = errors.New("storage is read-only")
+	ErrStreamNotFound    = errors.New("stream not found")
+	ErrSubscribeDisabled = errors.New("subscriptions disabled for this stream")
+	ErrStreamTooLarge    = errors.New("stream exceeds -max-stream-bytes")
+	ErrNotJSONL          = errors.New("file is not JSONL")
+	ErrMessageNotFound   = errors.New("message not found")
 )
 
+// ctxCheckEvery is how many scanned lines pass between context
+// cancellation checks in Read.
+const ctxCheckEvery = 64
+
+// readFilter restricts which lines Read includes. It is attached to the
+// request context by the HTTP layer (see withReadFilter in main.go) since
+// the durablestream.Storage interface has no way to pass per-request
+// options.
+type readFilter struct {
+	since          time.Time // include messages with timestamp >= since
+	includeUntimed bool      // whether lines without a timestamp pass the filter
+}
+
+type readFilterKey struct{}
+
+// withReadFilter returns ctx with f attached for Read to pick up.
+func withReadFilter(ctx context.Context, f *readFilter) context.Context {
+	return context.WithValue(ctx, readFilterKey{}, f)
+}
+
+func readFilterFrom(ctx context.Context) *readFilter {
+	f, _ := ctx.Value(readFilterKey{}).(*readFilter)
+	return f
+}
+
+// matches reports whether a raw JSONL line passes the filter. Lines that
+// fail still advance offsets; they just aren't included in the result.
+func (f *readFilter) matches(line []byte) bool {
+	var meta struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(line, &meta); err != nil || meta.Timestamp == "" {
+		return f.includeUntimed
+	}
+	ts, err := time.Parse(time.RFC3339, meta.Timestamp)
+	if err != nil {
+		return f.includeUntimed
+	}
+	return !ts.Before(f.since)
+}
+
+// maxReadLimitBytes caps how large a single read a client may request
+// via ?limit=. Set by -max-read-bytes.
+var maxReadLimitBytes = 8 << 20
+
+// readLimits carries client-requested read caps into Read, overriding
+// the handler's default limit.
+type readLimits struct {
+	bytes    int // maximum payload bytes (clamped to maxReadLimitBytes)
+	messages int // maximum number of messages, 0 for no cap
+	skip     int // number of messages to skip past the start offset
+}
+
+type readLimitsKey struct{}
+
+func withReadLimits(ctx context.Context, rl *readLimits) context.Context {
+	return context.WithValue(ctx, readLimitsKey{}, rl)
+}
+
+func readLimitsFrom(ctx context.Context) *readLimits {
+	rl, _ := ctx.Value(readLimitsKey{}).(*readLimits)
+	return rl
+}
+
+type projectionKey struct{}
+
+// withProjection attaches the ?fields= path list for Read to apply to
+// each returned message.
+func withProjection(ctx context.Context, paths []string) context.Context {
+	return context.WithValue(ctx, projectionKey{}, paths)
+}
+
+func projectionFrom(ctx context.Context) []string {
+	paths, _ := ctx.Value(projectionKey{}).([]string)
+	return paths
+}
+
+type normalizeKey struct{}
+
+// withNormalize marks the request as wanting ?normalize=1 content-shape
+// normalization applied to each returned message.
+func withNormalize(ctx context.Context) context.Context {
+	return context.WithValue(ctx, normalizeKey{}, true)
+}
+
+func normalizeFrom(ctx context.Context) bool {
+	v, _ := ctx.Value(normalizeKey{}).(bool)
+	return v
+}
+
+type annotateKey struct{}
+
+// withAnnotate marks the request as wanting ?annotate=1 UI hints wrapped
+// around each returned message.
+func withAnnotate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, annotateKey{}, true)
+}
+
+func annotateFrom(ctx context.Context) bool {
+	v, _ := ctx.Value(annotateKey{}).(bool)
+	return v
+}
+
+type skeletonKey struct{}
+
+// withSkeleton marks the request as wanting ?skeleton=1 envelope-only
+// messages: identity and threading fields without any content.
+func withSkeleton(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skeletonKey{}, true)
+}
+
+func skeletonFrom(ctx context.Context) bool {
+	v, _ := ctx.Value(skeletonKey{}).(bool)
+	return v
+}
+
+type subscribeTypesKey struct{}
+
+// withSubscribeTypes attaches the ?types= filter for Subscribe: only
+// new lines of these types wake the subscriber.
+func withSubscribeTypes(ctx context.Context, types map[string]bool) context.Context {
+	return context.WithValue(ctx, subscribeTypesKey{}, types)
+}
+
+func subscribeTypesFrom(ctx context.Context) map[string]bool {
+	types, _ := ctx.Value(subscribeTypesKey{}).(map[string]bool)
+	return types
+}
+
+// maxCoalesceWindow caps the per-subscription ?coalesce= window so a
+// client cannot ask the server to sit on notifications indefinitely.
+const maxCoalesceWindow = 5 * time.Second
+
+type coalesceKey struct{}
+
+// withCoalesce attaches the ?coalesce= window for Subscribe: wakeups
+// within the window merge into one notification at its trailing edge.
+func withCoalesce(ctx context.Context, window time.Duration) context.Context {
+	return context.WithValue(ctx, coalesceKey{}, window)
+}
+
+func coalesceFrom(ctx context.Context) time.Duration {
+	window, _ := ctx.Value(coalesceKey{}).(time.Duration)
+	return window
+}
+
+type dedupKey struct{}
+
+// withDedup marks the request as wanting ?dedup=1 suppression of
+// consecutive duplicate messages (retried writes can repeat a line).
+func withDedup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dedupKey{}, true)
+}
+
+func dedupFrom(ctx context.Context) bool {
+	v, _ := ctx.Value(dedupKey{}).(bool)
+	return v
+}
+
+type resetSignalKey struct{}
+
+// withResetSignal attaches a flag Read sets when the client's offset
+// lies past the tail (the file shrank since their last read), so the
+// HTTP layer can add the Stream-Reset-Required header.
+func withResetSignal(ctx context.Context, flag *bool) context.Context {
+	return context.WithValue(ctx, resetSignalKey{}, flag)
+}
+
+func signalResetRequired(ctx context.Context) {
+	if flag, _ := ctx.Value(resetSignalKey{}).(*bool); flag != nil {
+		*flag = true
+	}
+}
+
+// readDigest receives the CRC32 of the bytes a Read returned, so the
+// HTTP layer can surface it as a header and check preconditions against
+// it without re-reading the file.
+type readDigest struct {
+	sum uint32
+	set bool
+}
+
+// String formats the digest the way it travels in headers.
+func (d *readDigest) String() string {
+	return fmt.Sprintf("crc32:%08x", d.sum)
+}
+
+// readRange receives the exact byte boundaries of the chunk a Read
+// returned — start, end (exclusive) and the stream's total size — so
+// the HTTP layer can report them as Stream-Content-Range.
+type readRange struct {
+	start, end, total int64
+	set               bool
+}
+
+type readRangeKey struct{}
+
+func withReadRange(ctx context.Context, rng *readRange) context.Context {
+	return context.WithValue(ctx, readRangeKey{}, rng)
+}
+
+func readRangeFrom(ctx context.Context) *readRange {
+	rng, _ := ctx.Value(readRangeKey{}).(*readRange)
+	return rng
+}
+
+type readDigestKey struct{}
+
+func withReadDigest(ctx context.Context, d *readDigest) context.Context {
+	return context.WithValue(ctx, readDigestKey{}, d)
+}
+
+func readDigestFrom(ctx context.Context) *readDigest {
+	d, _ := ctx.Value(readDigestKey{}).(*readDigest)
+	return d
+}
+
+type forceReadKey struct{}
+
+// withForceRead marks the request (?force=1) as deliberately accessing
+// a stream over the -max-stream-bytes limit.
+func withForceRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceReadKey{}, true)
+}
+
+func forceReadFrom(ctx context.Context) bool {
+	v, _ := ctx.Value(forceReadKey{}).(bool)
+	return v
+}
+
+type compactViewKey struct{}
+
+// withCompactView marks the request as wanting the ?view=compact
+// transform applied to Read results.
+func withCompactView(ctx context.Context) context.Context {
+	return context.WithValue(ctx, compactViewKey{}, true)
+}
+
+func compactViewFrom(ctx context.Context) bool {
+	v, _ := ctx.Value(compactViewKey{}).(bool)
+	return v
+}
+
+// compactMessages collapses runs of consecutive tool_use/tool_result
+// messages into a single summary entry. Offsets still refer to the
+// original lines: a summary carries the offset of the last collapsed
+// line, plus FromOffset so the client can re-read the originals.
+func compactMessages(msgs []durablestream.StoredMessage, start durablestream.Offset) []durablestream.StoredMessage {
+	out := make([]durablestream.StoredMessage, 0, len(msgs))
+	prev := start
+
+	for i := 0; i < len(msgs); {
+		isTool, _, _ := toolActivity(msgs[i].Data)
+		if !isTool {
+			out = append(out, msgs[i])
+			prev = msgs[i].Offset
+			i++
+			continue
+		}
+
+		run := compactToolRun{Type: "tool_summary", FromOffset: string(prev)}
+		var last durablestream.StoredMessage
+		for i < len(msgs) {
+			ok, names, result := toolActivity(msgs[i].Data)
+			if !ok {
+				break
+			}
+			run.Count++
+			for _, n := range names {
+				if !slices.Contains(run.Tools, n) {
+					run.Tools = append(run.Tools, n)
+				}
+			}
+			if result != "" {
+				run.Preview = truncate(result, compactPreviewLen)
+			}
+			last = msgs[i]
+			i++
+		}
+
+		data, err := json.Marshal(run)
+		if err != nil {
+			continue
+		}
+		out = append(out, durablestream.StoredMessage{Data: data, Offset: last.Offset})
+		prev = last.Offset
+	}
+	return out
+}
+
 // ClaudeStorage implements durablestream.Storage as a read-only view
 // over Claude conversation JSONL files.
 //
@@ -34,104 +353,654 @@ type ClaudeStorage struct {
 	claudeDir   string // ~/.claude
 	projectsDir string // ~/.claude/projects
 
+	// noSubscribe is a glob; matching stream IDs can be read but not
+	// subscribed to (see -no-subscribe).
+	noSubscribe string
+
 	mu          sync.RWMutex
 	watcher     *fsnotify.Watcher
 	subscribers map[string][]chan durablestream.Offset // streamID -> channels
 	fileIndex   map[string]string                      // streamID -> file path
+	extraFiles  map[string]string                      // file path -> chosen stream ID (-extra-file)
+
+	watchCount    int                  // directories successfully watched
+	droppedEvents int                  // fsnotify overflow count (events were lost)
+	polledDirs    map[string]struct{}  // subtrees degraded to polling
+	pollSeen      map[string]time.Time // polled file -> last seen mtime
+	pollOnce      sync.Once
+	done          chan struct{}
+
+	fingerprints map[string]fileFingerprint // path -> last seen fingerprint
+
+	// currentID tracks the most-recently-written conversation (the likely
+	// active session), updated on every change notification.
+	currentID string
+	currentAt time.Time
+
+	// rewriteLocks serialize reads against detected in-place rewrites:
+	// a read holds its stream's lock shared, a rewrite holds it
+	// exclusively for a short settle window. Appends never take it.
+	rewriteLocks map[string]*sync.RWMutex
+
+	globalBucket *tokenBucket            // -read-rate throttle, lazily created
+	readBuckets  map[string]*tokenBucket // -stream-read-rate throttles by stream ID
+
+	// shutdownCh closes when graceful shutdown begins; streaming handlers
+	// select on it to emit a final shutdown event and hang up, so
+	// http.Server.Shutdown isn't held open by connections that would
+	// otherwise never end.
+	shutdownCh   chan struct{}
+	shutdownOnce sync.Once
+
+	audit *auditLogger // nil unless -audit-log is set
+
+	bookmarks *bookmarkStore // nil unless -bookmarks-file is set
+
+	annotations *annotationStore // nil unless -annotations-file is set
+
+	// ready flips once the initial index is complete. Reads work before
+	// that via the resolution fallback, but listings are incomplete and
+	// readiness probes should wait.
+	ready atomic.Bool
+
+	debugSubs []chan debugEvent // /_debug/events listeners
+
+	metaFeedSubs []chan string // /_metadata/feed listeners, carrying stream IDs
+
+	metaCache metadataCache
+	hashes    hashCache
+	history   historyCache
+	stats     statsCache
+	lines     lineIndexCache
+	gzSizes   gzSizeCache
+	sniffs    sniffCache
+}
+
+// sniffCache memoizes JSONL validity sniffs keyed by file path, so a
+// misnamed binary is only re-read when its size or mtime changes.
+type sniffCache struct {
+	mu      sync.Mutex
+	entries map[string]sniffEntry
+}
+
+type sniffEntry struct {
+	size    int64
+	modTime time.Time
+	ok      bool
 }
 
 // NewClaudeStorage creates a storage backed by the given Claude directory
 // (typically ~/.claude). It watches for file changes and indexes conversations.
 func NewClaudeStorage(claudeDir string) (*ClaudeStorage, error) {
+	// Preflight the directory layout so misconfiguration fails fast with
+	// an actionable message instead of an empty stream list.
+	projectsDir := filepath.Join(claudeDir, "projects")
+	info, err := os.Stat(projectsDir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// Fresh machine: the CLI creates this on first use. Watch for it
+		// to appear (see watchLoop) instead of refusing to start.
+		log.Printf("Warning: projects directory %s does not exist yet; waiting for it to be created", projectsDir)
+	case err != nil:
+		return nil, fmt.Errorf("projects directory %s: %w (is -dir pointing at a Claude directory?)", projectsDir, err)
+	case !info.IsDir():
+		return nil, fmt.Errorf("projects path %s is not a directory", projectsDir)
+	default:
+		if entries, err := os.ReadDir(projectsDir); err == nil && len(entries) == 0 {
+			log.Printf("Warning: projects directory %s is empty; no conversations to serve yet", projectsDir)
+		}
+	}
+	historyPath := filepath.Join(claudeDir, "history.jsonl")
+	if !noHistory {
+		if _, err := os.Stat(historyPath); err == nil {
+			f, err := os.Open(historyPath)
+			if err != nil {
+				return nil, fmt.Errorf("history file %s exists but is not readable: %w", historyPath, err)
+			}
+			f.Close()
+		}
+	}
+
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("create watcher: %w", err)
 	}
 
 	s := &ClaudeStorage{
-		claudeDir:   claudeDir,
-		projectsDir: filepath.Join(claudeDir, "projects"),
-		watcher:     watcher,
-		subscribers: make(map[string][]chan durablestream.Offset),
-		fileIndex:   make(map[string]string),
+		claudeDir:    claudeDir,
+		projectsDir:  projectsDir,
+		watcher:      watcher,
+		subscribers:  make(map[string][]chan durablestream.Offset),
+		fileIndex:    make(map[string]string),
+		polledDirs:   make(map[string]struct{}),
+		pollSeen:     make(map[string]time.Time),
+		done:         make(chan struct{}),
+		metaCache:    metadataCache{entries: make(map[string]metadataCacheEntry)},
+		hashes:       hashCache{entries: make(map[string]hashCacheEntry)},
+		stats:        statsCache{entries: make(map[string]statsCacheEntry)},
+		lines:        lineIndexCache{entries: make(map[string]*lineIndexEntry)},
+		fingerprints: make(map[string]fileFingerprint),
+		shutdownCh:   make(chan struct{}),
+		gzSizes:      gzSizeCache{entries: make(map[string]gzSizeEntry)},
+		sniffs:       sniffCache{entries: make(map[string]sniffEntry)},
 	}
 
-	// Register special streams
-	historyPath := filepath.Join(claudeDir, "history.jsonl")
-	s.fileIndex["_history"] = historyPath
-	watcher.Add(claudeDir) // watch for history.jsonl changes
+	// Register special streams. -no-history keeps command history off
+	// the server entirely: not indexed, not watched.
+	if !noHistory {
+		s.fileIndex["_history"] = historyPath
+		s.addWatch(claudeDir) // watch for history.jsonl changes
+	}
+
+	// A persisted index (-index-cache) makes startup on huge trees
+	// immediate: serve from the cached entries right away and reconcile
+	// with a full background reindex. Stale cached paths are pruned
+	// lazily by resolveLive on first access.
+	cached := false
+	if indexCachePath != "" {
+		if idx, err := loadIndexCache(indexCachePath); err == nil && len(idx) > 0 {
+			s.mu.Lock()
+			for id, p := range idx {
+				if _, ok := s.fileIndex[id]; !ok {
+					s.fileIndex[id] = p
+				}
+			}
+			s.mu.Unlock()
+			cached = true
+			log.Printf("Loaded %d index entries from %s", len(idx), indexCachePath)
+		}
+	}
+
+	if cached {
+		s.ready.Store(true)
+		go func() {
+			if err := s.indexFiles(); err != nil {
+				log.Printf("background reindex: %v", err)
+				return
+			}
+			s.saveIndexCache()
+		}()
+	} else {
+		// Build initial index and watch project directories
+		if err := s.indexFiles(); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("index files: %w", err)
+		}
+		s.ready.Store(true)
+		s.saveIndexCache()
+	}
+
+	go s.watchLoop()
+
+	return s, nil
+}
+
+// NewSingleFileStorage creates a storage that serves exactly one JSONL
+// file, with the stream ID taken from its basename. Only that file is
+// watched; the projects tree is never indexed and _history is not
+// available in this mode.
+func NewSingleFileStorage(path string) (*ClaudeStorage, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
 
-	// Build initial index and watch project directories
-	if err := s.indexFiles(); err != nil {
-		watcher.Close()
-		return nil, fmt.Errorf("index files: %w", err)
+	s := &ClaudeStorage{
+		claudeDir:    filepath.Dir(path),
+		subscribers:  make(map[string][]chan durablestream.Offset),
+		fileIndex:    make(map[string]string),
+		polledDirs:   make(map[string]struct{}),
+		pollSeen:     make(map[string]time.Time),
+		done:         make(chan struct{}),
+		watcher:      watcher,
+		metaCache:    metadataCache{entries: make(map[string]metadataCacheEntry)},
+		hashes:       hashCache{entries: make(map[string]hashCacheEntry)},
+		stats:        statsCache{entries: make(map[string]statsCacheEntry)},
+		lines:        lineIndexCache{entries: make(map[string]*lineIndexEntry)},
+		fingerprints: make(map[string]fileFingerprint),
+		shutdownCh:   make(chan struct{}),
+		gzSizes:      gzSizeCache{entries: make(map[string]gzSizeEntry)},
+		sniffs:       sniffCache{entries: make(map[string]sniffEntry)},
 	}
 
+	streamID := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+	s.fileIndex[streamID] = path
+	s.addWatch(filepath.Dir(path))
+	s.ready.Store(true)
+
 	go s.watchLoop()
 
 	return s, nil
 }
 
+// indexFiles rebuilds the stream index. The walk fills a fresh map that
+// is swapped in atomically under the lock at the end, so concurrent
+// readers never observe a half-built index during a rebuild.
 func (s *ClaudeStorage) indexFiles() error {
-	return filepath.WalkDir(s.projectsDir, func(path string, d os.DirEntry, err error) error {
+	fresh := make(map[string]string)
+	sidecars := 0
+	skipped := 0
+
+	err := filepath.WalkDir(s.projectsDir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil // skip errors
 		}
 		if d.IsDir() {
-			s.watcher.Add(path)
+			s.addWatch(path)
+			return nil
+		}
+		if strings.HasSuffix(path, ".meta.json") {
+			sidecars++
+			return nil
+		}
+		if strings.HasSuffix(path, ".jsonl.gz") {
+			// Gzipped archives only serve a stream when no live .jsonl
+			// exists for the same UUID; the plain file always wins.
+			streamID := strings.TrimSuffix(filepath.Base(path), ".jsonl.gz")
+			if _, ok := fresh[streamID]; !ok {
+				fresh[streamID] = path
+			}
 			return nil
 		}
 		if strings.HasSuffix(path, ".jsonl") {
+			if oversized(d) {
+				skipped++
+				return nil
+			}
 			streamID := strings.TrimSuffix(filepath.Base(path), ".jsonl")
-			s.mu.Lock()
-			s.fileIndex[streamID] = path
-			s.mu.Unlock()
+			fresh[streamID] = path
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	if sidecars > 0 {
+		log.Printf("Found %d sidecar annotation file(s)", sidecars)
+	}
+	if skipped > 0 {
+		log.Printf("Skipped %d file(s) over -max-stream-bytes (%d)", skipped, maxStreamBytes)
+	}
+
+	s.mu.Lock()
+	// Carry over special streams and any entries resolved while the
+	// walk was running.
+	for id, path := range s.fileIndex {
+		if _, ok := fresh[id]; !ok {
+			fresh[id] = path
+		}
+	}
+	s.fileIndex = fresh
+	s.mu.Unlock()
+	return nil
 }
 
-func (s *ClaudeStorage) watchLoop() {
-	historyPath := filepath.Join(s.claudeDir, "history.jsonl")
+// oversized reports whether a walked file is over -max-stream-bytes.
+func oversized(d os.DirEntry) bool {
+	if maxStreamBytes <= 0 {
+		return false
+	}
+	info, err := d.Info()
+	if err != nil {
+		return false
+	}
+	return info.Size() > maxStreamBytes
+}
+
+// watcherAdd wraps fsnotify watch registration so tests can inject
+// watch-limit failures without exhausting the real kernel limit.
+var watcherAdd = func(w *fsnotify.Watcher, dir string) error { return w.Add(dir) }
+
+// pollInterval is how often subtrees degraded from inotify are
+// rescanned for modified files.
+var pollInterval = 2 * time.Second
+
+// addWatch registers a directory with the fsnotify watcher. When the
+// kernel watch limit is exhausted the subtree degrades to polling so
+// the server stays functional on deep project trees.
+func (s *ClaudeStorage) addWatch(dir string) {
+	if err := watcherAdd(s.watcher, dir); err != nil {
+		if isWatchLimitErr(err) {
+			s.mu.Lock()
+			s.polledDirs[dir] = struct{}{}
+			s.mu.Unlock()
+			log.Printf("watch limit reached, falling back to polling: %s", dir)
+			s.pollOnce.Do(func() { go s.pollLoop() })
+		}
+		return
+	}
+	s.mu.Lock()
+	s.watchCount++
+	s.mu.Unlock()
+}
+
+// isWatchLimitErr reports whether err means the inotify instance or
+// descriptor limit was hit.
+func isWatchLimitErr(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) ||
+		errors.Is(err, syscall.EMFILE) ||
+		errors.Is(err, syscall.ENFILE)
+}
+
+// pollLoop periodically scans polled directories for modified .jsonl
+// files. It only runs once some subtree has degraded from inotify.
+func (s *ClaudeStorage) pollLoop() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
 
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.RLock()
+			dirs := make([]string, 0, len(s.polledDirs))
+			for dir := range s.polledDirs {
+				dirs = append(dirs, dir)
+			}
+			s.mu.RUnlock()
+
+			for _, dir := range dirs {
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					continue
+				}
+				for _, e := range entries {
+					if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+						continue
+					}
+					info, err := e.Info()
+					if err != nil {
+						continue
+					}
+					path := filepath.Join(dir, e.Name())
+					s.mu.Lock()
+					seen, ok := s.pollSeen[path]
+					s.pollSeen[path] = info.ModTime()
+					s.mu.Unlock()
+					if !ok || info.ModTime().After(seen) {
+						s.notifyChange(path)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (s *ClaudeStorage) watchLoop() {
 	for {
 		select {
 		case event, ok := <-s.watcher.Events:
 			if !ok {
 				return
 			}
+			s.publishDebugEvent(event)
 			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
 				if strings.HasSuffix(event.Name, ".jsonl") {
-					var streamID string
-					if event.Name == historyPath {
-						streamID = "_history"
-					} else {
-						streamID = strings.TrimSuffix(filepath.Base(event.Name), ".jsonl")
-						s.mu.Lock()
-						s.fileIndex[streamID] = event.Name
-						s.mu.Unlock()
-					}
-
-					// Get new tail offset
-					tail, _ := s.getTailOffset(event.Name)
-
-					// Notify subscribers
-					s.mu.RLock()
-					for _, ch := range s.subscribers[streamID] {
-						select {
-						case ch <- tail:
-						default: // don't block
-						}
+					s.notifyChange(event.Name)
+				}
+				// The projects directory appearing on a fresh machine:
+				// index it now and pick up watches on its subtree.
+				if event.Name == s.projectsDir && s.projectsDir != "" {
+					log.Printf("projects directory created, indexing: %s", s.projectsDir)
+					if err := s.indexFiles(); err != nil {
+						log.Printf("index after projects dir created: %v", err)
 					}
-					s.mu.RUnlock()
 				}
 			}
-		case _, ok := <-s.watcher.Errors:
+			// inotify watches don't recurse: a directory created after
+			// startup (new project, nested subtree) is invisible until it
+			// gets its own watch. Register it and sweep anything that
+			// landed inside before the watch took effect.
+			if event.Op&fsnotify.Create != 0 && event.Name != s.projectsDir {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					s.watchNewDir(event.Name)
+				}
+			}
+		case err, ok := <-s.watcher.Errors:
 			if !ok {
 				return
 			}
+			// A kernel-side queue overflow means events were dropped and
+			// some changes were never seen; rescan to recover.
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				s.mu.Lock()
+				s.droppedEvents++
+				s.mu.Unlock()
+				log.Printf("fsnotify event overflow, rescanning recently-active streams")
+				s.rescanAfterOverflow()
+			}
+		}
+	}
+}
+
+// watchNewDir registers watches across a directory subtree created
+// after startup and indexes any .jsonl files already inside it, closing
+// the race between a mkdir and the first writes into it.
+func (s *ClaudeStorage) watchNewDir(dir string) {
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			s.addWatch(path)
+			return nil
+		}
+		if strings.HasSuffix(path, ".jsonl") {
+			s.notifyChange(path)
+		}
+		return nil
+	})
+}
+
+// overflowRescanWindow is how far back a file's mtime may be for it to
+// count as recently active during overflow recovery.
+const overflowRescanWindow = time.Minute
+
+// rescanAfterOverflow re-indexes the projects tree and nudges
+// subscribers of every stream modified recently, since their change
+// notifications may have been among the dropped events.
+func (s *ClaudeStorage) rescanAfterOverflow() {
+	if s.projectsDir != "" {
+		if err := s.indexFiles(); err != nil {
+			log.Printf("rescan after overflow: %v", err)
+		}
+	}
+
+	cutoff := time.Now().Add(-overflowRescanWindow)
+	s.mu.RLock()
+	paths := make([]string, 0, len(s.fileIndex))
+	for _, path := range s.fileIndex {
+		paths = append(paths, path)
+	}
+	s.mu.RUnlock()
+
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(cutoff) {
+			s.notifyChange(path)
+		}
+	}
+}
+
+// resolveLive resolves a stream ID and verifies the file still exists.
+// A moved file (project renamed) leaves a stale index entry behind; on
+// ENOENT the entry is dropped and resolution re-runs the bounded walk,
+// so reads recover without a restart.
+func (s *ClaudeStorage) resolveLive(ctx context.Context, streamID string) (string, error) {
+	path, err := s.getPathCtx(ctx, streamID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil || !errors.Is(err, os.ErrNotExist) {
+		return path, nil
+	}
+	if streamID == "_history" {
+		return "", ErrStreamNotFound
+	}
+
+	s.mu.Lock()
+	if s.fileIndex[strings.TrimPrefix(streamID, "/")] == path {
+		delete(s.fileIndex, strings.TrimPrefix(streamID, "/"))
+	}
+	s.mu.Unlock()
+	s.invalidateDerived(path)
+
+	return s.getPathCtx(ctx, streamID)
+}
+
+// registerExtraFile serves a specific JSONL file outside the projects
+// tree under a chosen stream ID (-extra-file). The file's directory is
+// watched so appends notify subscribers like any other stream; the
+// stream is read-only like everything else.
+func (s *ClaudeStorage) registerExtraFile(id, path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("extra file path must be absolute: %s", path)
+	}
+	path = filepath.Clean(path)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("extra file: %w", err)
+	}
+
+	s.mu.Lock()
+	if existing, ok := s.fileIndex[id]; ok && existing != path {
+		s.mu.Unlock()
+		return fmt.Errorf("stream ID %q already in use", id)
+	}
+	s.fileIndex[id] = path
+	if s.extraFiles == nil {
+		s.extraFiles = make(map[string]string)
+	}
+	s.extraFiles[path] = id
+	s.mu.Unlock()
+
+	s.addWatch(filepath.Dir(path))
+	return nil
+}
+
+// fileFingerprint identifies a file's content state beyond its size, so
+// a rewrite that happens to preserve the byte length is still detected.
+type fileFingerprint struct {
+	size    int64
+	modTime time.Time
+	inode   uint64
+}
+
+// rewriteSettleDelay is how long a detected rewrite blocks new reads,
+// giving the writer time to finish producing the replacement content.
+const rewriteSettleDelay = 100 * time.Millisecond
+
+// rewriteLock returns the per-stream lock guarding reads against
+// rewrites, creating it on first use.
+func (s *ClaudeStorage) rewriteLock(path string) *sync.RWMutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rewriteLocks == nil {
+		s.rewriteLocks = make(map[string]*sync.RWMutex)
+	}
+	lk, ok := s.rewriteLocks[path]
+	if !ok {
+		lk = &sync.RWMutex{}
+		s.rewriteLocks[path] = lk
+	}
+	return lk
+}
+
+// invalidateDerived drops all per-file cached state for a path whose
+// content changed without the size-keyed caches noticing.
+func (s *ClaudeStorage) invalidateDerived(path string) {
+	s.metaCache.mu.Lock()
+	delete(s.metaCache.entries, path)
+	s.metaCache.mu.Unlock()
+
+	s.hashes.mu.Lock()
+	delete(s.hashes.entries, path)
+	s.hashes.mu.Unlock()
+
+	s.stats.mu.Lock()
+	delete(s.stats.entries, path)
+	s.stats.mu.Unlock()
+
+	s.lines.mu.Lock()
+	delete(s.lines.entries, path)
+	s.lines.mu.Unlock()
+
+	s.sniffs.mu.Lock()
+	delete(s.sniffs.entries, path)
+	s.sniffs.mu.Unlock()
+}
+
+// notifyChange updates the index for a changed file and wakes any
+// subscribers on the corresponding stream.
+func (s *ClaudeStorage) notifyChange(path string) {
+	historyPath := filepath.Join(s.claudeDir, "history.jsonl")
+
+	s.mu.RLock()
+	extraID, isExtra := s.extraFiles[path]
+	s.mu.RUnlock()
+
+	var streamID string
+	switch {
+	case path == historyPath:
+		streamID = "_history"
+	case isExtra:
+		// Externally registered files keep their chosen ID rather than
+		// deriving one from the basename.
+		streamID = extraID
+	default:
+		streamID = strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		s.mu.Lock()
+		s.fileIndex[streamID] = path
+		s.mu.Unlock()
+	}
+
+	// An in-place rewrite can leave the size unchanged, which would let
+	// size-keyed caches serve stale data. Fingerprint each file and drop
+	// its derived state when the content changed without growing.
+	if info, err := os.Stat(path); err == nil {
+		fp := fileFingerprint{size: info.Size(), modTime: info.ModTime(), inode: inodeOf(info)}
+		s.mu.Lock()
+		prev, seen := s.fingerprints[path]
+		s.fingerprints[path] = fp
+		// Track the likely active session; mod-time breaks ties between
+		// near-simultaneous writers.
+		if streamID != "_history" && !info.ModTime().Before(s.currentAt) {
+			s.currentID = streamID
+			s.currentAt = info.ModTime()
+		}
+		s.mu.Unlock()
+		if seen && prev.size == fp.size && prev != fp {
+			// Hold the stream's rewrite lock exclusively for a settle
+			// window: in-flight reads finish against the old content,
+			// new reads wait briefly for the rewrite to complete. Done
+			// off the watch loop so event handling isn't stalled.
+			lk := s.rewriteLock(path)
+			go func() {
+				lk.Lock()
+				time.Sleep(rewriteSettleDelay)
+				lk.Unlock()
+			}()
+			s.invalidateDerived(path)
 		}
 	}
+
+	// Get new tail offset
+	tail, _ := s.getTailOffset(path)
+
+	// Notify subscribers
+	s.mu.RLock()
+	for _, ch := range s.subscribers[streamID] {
+		select {
+		case ch <- tail:
+		default: // don't block
+		}
+	}
+	s.mu.RUnlock()
+
+	s.publishMetaChange(streamID)
 }
 
 func (s *ClaudeStorage) getTailOffset(path string) (durablestream.Offset, error) {
@@ -142,6 +1011,54 @@ func (s *ClaudeStorage) getTailOffset(path string) (durablestream.Offset, error)
 	return offsetFromInt(info.Size()), nil
 }
 
+// scanLinesKeepCR is like bufio.ScanLines but leaves a trailing \r on
+// the token, so callers can account for CRLF terminators when doing
+// byte-accurate offset math.
+func scanLinesKeepCR(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[0:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// offsetAfterUUID scans a stream for the message with the given uuid
+// and returns the byte offset just past its line (in uncompressed bytes
+// for gzipped streams).
+func offsetAfterUUID(path, uuid string) (int64, error) {
+	rc, err := openStream(path)
+	if err != nil {
+		return 0, fmt.Errorf("open: %w", err)
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+	scanner.Split(scanLinesKeepCR)
+
+	var pos int64
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		pos += int64(len(line) + 1)
+		var entry struct {
+			UUID string `json:"uuid"`
+		}
+		if json.Unmarshal(line, &entry) == nil && entry.UUID == uuid {
+			return pos, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("scan: %w", err)
+	}
+	return 0, ErrMessageNotFound
+}
+
 func offsetFromInt(n int64) durablestream.Offset {
 	return durablestream.Offset(fmt.Sprintf("%020d", n))
 }
@@ -155,33 +1072,92 @@ func offsetToInt(o durablestream.Offset) int64 {
 }
 
 func (s *ClaudeStorage) getPath(streamID string) (string, error) {
+	return s.getPathCtx(context.Background(), streamID)
+}
+
+// walkFallbackMaxFiles caps how many files the deep fallback walk in
+// getPathCtx may visit before giving up; an unbounded walk over a huge
+// projects tree is both slow and a latent DoS.
+const walkFallbackMaxFiles = 50000
+
+// walkFallbackMaxAge prunes project directories untouched for this long
+// from the fallback walk; a conversation being requested by ID is
+// almost always in a recently active project.
+const walkFallbackMaxAge = 90 * 24 * time.Hour
+
+// getPathCtx resolves a stream ID to a file path, consulting the index
+// first and falling back to a bounded filesystem search. The walk stops
+// when ctx is canceled so a disconnected client doesn't pay for it.
+func (s *ClaudeStorage) getPathCtx(ctx context.Context, streamID string) (string, error) {
 	// Strip leading slash from URL path
 	streamID = strings.TrimPrefix(streamID, "/")
 
+	if noHistory && streamID == "_history" {
+		return "", ErrStreamNotFound
+	}
+
 	s.mu.RLock()
 	path, ok := s.fileIndex[streamID]
 	s.mu.RUnlock()
 
 	if ok {
+		if err := checkStreamSize(ctx, path); err != nil {
+			return "", err
+		}
+		if err := s.checkJSONL(path); err != nil {
+			return "", err
+		}
 		return path, nil
 	}
 
+	// Single-file mode has no projects tree to search.
+	if s.projectsDir == "" {
+		return "", ErrStreamNotFound
+	}
+
 	// Try to find it in projects
 	pattern := filepath.Join(s.projectsDir, "**", streamID+".jsonl")
 	matches, err := filepath.Glob(pattern)
 	if err == nil && len(matches) > 0 {
+		if err := checkStreamSize(ctx, matches[0]); err != nil {
+			return "", err
+		}
+		if err := s.checkJSONL(matches[0]); err != nil {
+			return "", err
+		}
 		s.mu.Lock()
 		s.fileIndex[streamID] = matches[0]
 		s.mu.Unlock()
 		return matches[0], nil
 	}
 
-	// Deeper search
-	var found string
+	// Deeper search, bounded in files visited, directory age and by the
+	// request context.
+	var (
+		found   string
+		visited int
+		cutoff  = time.Now().Add(-walkFallbackMaxAge)
+	)
 	filepath.WalkDir(s.projectsDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path == s.projectsDir {
+				return nil
+			}
+			if info, err := d.Info(); err == nil && info.ModTime().Before(cutoff) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
+		visited++
+		if visited > walkFallbackMaxFiles {
+			return filepath.SkipAll
+		}
+		if visited%ctxCheckEvery == 0 && ctx.Err() != nil {
+			return filepath.SkipAll
+		}
 		if filepath.Base(path) == streamID+".jsonl" {
 			found = path
 			return filepath.SkipAll
@@ -189,6 +1165,12 @@ func (s *ClaudeStorage) getPath(streamID string) (string, error) {
 		return nil
 	})
 	if found != "" {
+		if err := checkStreamSize(ctx, found); err != nil {
+			return "", err
+		}
+		if err := s.checkJSONL(found); err != nil {
+			return "", err
+		}
 		s.mu.Lock()
 		s.fileIndex[streamID] = found
 		s.mu.Unlock()
@@ -198,6 +1180,79 @@ func (s *ClaudeStorage) getPath(streamID string) (string, error) {
 	return "", ErrStreamNotFound
 }
 
+// sniffLimit bounds how much of a file the JSONL sniff will read.
+const sniffLimit = 64 * 1024
+
+// checkJSONL rejects resolution of a .jsonl file whose first line is
+// not JSON, so a stray binary with the right name yields a clear error
+// instead of streamed garbage. Results are memoized per size+mtime.
+func (s *ClaudeStorage) checkJSONL(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil // let the caller's own stat report the real error
+	}
+
+	s.sniffs.mu.Lock()
+	if e, ok := s.sniffs.entries[path]; ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		s.sniffs.mu.Unlock()
+		if !e.ok {
+			return ErrNotJSONL
+		}
+		return nil
+	}
+	s.sniffs.mu.Unlock()
+
+	ok := sniffJSONL(path)
+
+	s.sniffs.mu.Lock()
+	s.sniffs.entries[path] = sniffEntry{size: info.Size(), modTime: info.ModTime(), ok: ok}
+	s.sniffs.mu.Unlock()
+
+	if !ok {
+		return ErrNotJSONL
+	}
+	return nil
+}
+
+// sniffJSONL checks that a file's first line parses as JSON. Only the
+// first sniffLimit bytes are read, so the check stays cheap on huge
+// files; an over-long first line settles for a shape check.
+func sniffJSONL(path string) bool {
+	rc, err := openStream(path)
+	if err != nil {
+		return true // unreadable files fail later with the real error
+	}
+	defer rc.Close()
+
+	br := bufio.NewReaderSize(rc, sniffLimit)
+	line, err := br.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		trimmed := bytes.TrimLeft(line, " \t")
+		return len(trimmed) > 0 && trimmed[0] == '{'
+	}
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return true // brand-new conversations start as empty files
+	}
+	return json.Valid(line)
+}
+
+// checkStreamSize rejects resolution of a stream over -max-stream-bytes
+// unless the request carries the ?force=1 override.
+func checkStreamSize(ctx context.Context, path string) error {
+	if maxStreamBytes <= 0 || forceReadFrom(ctx) {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil // let the caller's own stat report the real error
+	}
+	if info.Size() > maxStreamBytes {
+		return ErrStreamTooLarge
+	}
+	return nil
+}
+
 // Create is not supported (read-only storage).
 func (s *ClaudeStorage) Create(ctx context.Context, streamID string, cfg durablestream.StreamConfig) (bool, error) {
 	return false, ErrReadOnly
@@ -220,7 +1275,7 @@ func (s *ClaudeStorage) Delete(ctx context.Context, streamID string) error {
 
 // Head returns stream metadata.
 func (s *ClaudeStorage) Head(ctx context.Context, streamID string) (*durablestream.StreamInfo, error) {
-	path, err := s.getPath(streamID)
+	path, err := s.resolveLive(ctx, streamID)
 	if err != nil {
 		return nil, err
 	}
@@ -230,52 +1285,198 @@ func (s *ClaudeStorage) Head(ctx context.Context, streamID string) (*durablestre
 		return nil, fmt.Errorf("stat: %w", err)
 	}
 
+	contentType := "application/json"
+	if sc := readSidecar(path); sc != nil && sc.ContentType != "" {
+		contentType = sc.ContentType
+	}
+
+	// Gzipped streams report their uncompressed size, so Head and Read
+	// agree on offsets regardless of on-disk representation.
+	size := info.Size()
+	if isGzipPath(path) {
+		size, err = s.uncompressedSize(path)
+		if err != nil {
+			return nil, fmt.Errorf("uncompressed size: %w", err)
+		}
+	}
+
 	return &durablestream.StreamInfo{
-		ContentType: "application/json",
-		NextOffset:  offsetFromInt(info.Size()),
+		ContentType: contentType,
+		NextOffset:  offsetFromInt(size),
 	}, nil
 }
 
 // Read returns messages from offset.
 func (s *ClaudeStorage) Read(ctx context.Context, streamID string, offset durablestream.Offset, limit int) (*durablestream.ReadResult, error) {
-	path, err := s.getPath(streamID)
+	path, err := s.resolveLive(ctx, streamID)
 	if err != nil {
 		return nil, err
 	}
 
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("open: %w", err)
+	// ?merge=1 presents a resume chain as one logical stream with
+	// combined offsets (see merge.go).
+	if mergedReadFrom(ctx) {
+		if paths := s.chainPaths(streamID); len(paths) > 1 {
+			return s.readMerged(ctx, paths, offset, limit)
+		}
 	}
-	defer f.Close()
 
 	startOffset := offsetToInt(offset)
-	if _, err := f.Seek(startOffset, io.SeekStart); err != nil {
-		return nil, fmt.Errorf("seek: %w", err)
+
+	// A read either sees the old complete file or waits out a detected
+	// rewrite's settle window; plain appends never hold this lock, so
+	// tailing is unaffected.
+	lk := s.rewriteLock(path)
+	lk.RLock()
+	defer lk.RUnlock()
+
+	// A UUID-shaped offset names a message instead of a byte position:
+	// resume just after that message. Identity survives rewrites that
+	// shift byte offsets, so it's the robust cursor for clients that
+	// track message UUIDs anyway.
+	if uuidRe.MatchString(string(offset)) {
+		startOffset, err = offsetAfterUUID(path, string(offset))
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	info, err := f.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("stat: %w", err)
+	var (
+		src        io.Reader
+		tailOffset durablestream.Offset
+	)
+	if isGzipPath(path) {
+		// Gzip members aren't seekable, so reading from an offset means
+		// decompressing and discarding the prefix. Offsets are always in
+		// the uncompressed byte stream.
+		total, err := s.uncompressedSize(path)
+		if err != nil {
+			return nil, fmt.Errorf("uncompressed size: %w", err)
+		}
+		tailOffset = offsetFromInt(total)
+
+		// ?skip=N works the same as on plain files: the line index holds
+		// uncompressed offsets, so it lands the start on a line boundary.
+		if rl := readLimitsFrom(ctx); rl != nil && rl.skip > 0 {
+			offsets, err := s.lineOffsets(path)
+			if err != nil {
+				return nil, fmt.Errorf("line index: %w", err)
+			}
+			startOffset = skipLines(offsets, startOffset, rl.skip)
+		}
+
+		if startOffset > total {
+			signalResetRequired(ctx)
+			return &durablestream.ReadResult{
+				NextOffset: tailOffset,
+				TailOffset: tailOffset,
+			}, nil
+		}
+
+		rc, err := openStream(path)
+		if err != nil {
+			return nil, fmt.Errorf("open: %w", err)
+		}
+		defer rc.Close()
+		if startOffset > 0 {
+			if _, err := io.CopyN(io.Discard, rc, startOffset); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("skip to offset: %w", err)
+			}
+		}
+		src = rc
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open: %w", err)
+		}
+		defer f.Close()
+
+		// ?skip=N advances the start by N lines via the line index, which
+		// avoids scanning and discarding the skipped messages.
+		if rl := readLimitsFrom(ctx); rl != nil && rl.skip > 0 {
+			offsets, err := s.lineOffsets(path)
+			if err != nil {
+				return nil, fmt.Errorf("line index: %w", err)
+			}
+			startOffset = skipLines(offsets, startOffset, rl.skip)
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("stat: %w", err)
+		}
+		tailOffset = offsetFromInt(info.Size())
+
+		// A cursor past the tail means the file shrank (truncated and
+		// rewritten) since the client last read. Don't error, and don't
+		// silently reset: return an empty page at the new tail and flag
+		// the response so the client knows to discard its cursor.
+		if startOffset > info.Size() {
+			signalResetRequired(ctx)
+			return &durablestream.ReadResult{
+				NextOffset: tailOffset,
+				TailOffset: tailOffset,
+			}, nil
+		}
+
+		// With -mmap the scan runs over a memory mapping instead of
+		// buffered file reads; on unsupported platforms or mapping
+		// failure we quietly fall back to the regular path.
+		src = f
+		if useMmap {
+			if m, err := mapFile(f, info.Size()); err == nil {
+				defer m.Close()
+				if startOffset < int64(len(m.data)) {
+					src = bytes.NewReader(m.data[startOffset:])
+				} else {
+					src = bytes.NewReader(nil)
+				}
+			} else if _, err := f.Seek(startOffset, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("seek: %w", err)
+			}
+		} else if _, err := f.Seek(startOffset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("seek: %w", err)
+		}
+	}
+
+	maxMessages := 0
+	if rl := readLimitsFrom(ctx); rl != nil {
+		if rl.bytes > 0 {
+			limit = min(rl.bytes, maxReadLimitBytes)
+		}
+		maxMessages = rl.messages
 	}
-	tailOffset := offsetFromInt(info.Size())
 
 	var messages []durablestream.StoredMessage
+	var prevIncluded []byte // last included line, for ?dedup=1
 	currentOffset := startOffset
 	bytesRead := 0
 
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(src)
 	// Handle potentially large lines (some history entries can be >1MB)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 16*1024*1024) // 16MB max line size
+	scanner.Split(scanLinesKeepCR)
 
+	lines := 0
 	for scanner.Scan() {
-		line := scanner.Bytes()
-		lineLen := int64(len(line) + 1) // +1 for newline
+		// Bail out promptly if the client went away; a disconnected
+		// reader shouldn't pay for the rest of a large scan.
+		lines++
+		if lines%ctxCheckEvery == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		raw := scanner.Bytes()
+		lineLen := int64(len(raw) + 1) // +1 for newline; raw keeps any \r
+		line := bytes.TrimSuffix(raw, []byte{'\r'})
 
 		if bytesRead+len(line) > limit && len(messages) > 0 {
 			break
 		}
+		if maxMessages > 0 && len(messages) >= maxMessages {
+			break
+		}
 
 		// Validate JSON before including - skip malformed lines
 		// (can happen if file is being written while we read)
@@ -284,27 +1485,102 @@ func (s *ClaudeStorage) Read(ctx context.Context, streamID string, offset durabl
 			continue
 		}
 
+		// Apply any request-scoped filter; skipped lines still advance
+		// the offset so pagination stays byte-accurate.
+		if filter := readFilterFrom(ctx); filter != nil && !filter.matches(line) {
+			currentOffset += lineLen
+			continue
+		}
+
+		// Suppress consecutive duplicates under ?dedup=1; like filtered
+		// lines they still advance the offset.
+		if dedupFrom(ctx) {
+			if duplicateLine(line, prevIncluded) {
+				currentOffset += lineLen
+				continue
+			}
+			prevIncluded = append(prevIncluded[:0], line...)
+		}
+
 		// For JSON mode, store raw JSON object (handler will format as array)
 		data := make([]byte, len(line))
 		copy(data, line)
 
+		// Normalize content shape before projection so a projected
+		// message.content is already in block form.
+		if normalizeFrom(ctx) {
+			data = normalizeContent(data)
+		}
+
+		// Project down to requested fields; offsets still refer to the
+		// full original lines.
+		if paths := projectionFrom(ctx); len(paths) > 0 {
+			data = projectFields(data, paths)
+		}
+
+		// Skeleton mode strips content entirely, leaving just the
+		// envelope for structure-only views.
+		if skeletonFrom(ctx) {
+			data = skeletonEntry(data)
+		}
+
+		// Annotation wraps last, so hints reflect whatever shape the
+		// transforms above produced.
+		if annotateFrom(ctx) {
+			data = annotateEntry(data)
+		}
+
 		currentOffset += lineLen
 		messages = append(messages, durablestream.StoredMessage{
 			Data:   data,
 			Offset: offsetFromInt(currentOffset),
 		})
 		bytesRead += len(line)
+		if dg := readDigestFrom(ctx); dg != nil {
+			dg.sum = crc32.Update(dg.sum, crc32.IEEETable, data)
+		}
+
+		// Pace against the -read-rate/-stream-read-rate budgets so many
+		// simultaneous large reads don't saturate disk I/O.
+		if err := s.throttleRead(ctx, streamID, len(line)); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("scan: %w", err)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	nextOffset := offsetFromInt(currentOffset)
 	if len(messages) == 0 {
 		nextOffset = offset
 	}
 
+	if compactViewFrom(ctx) {
+		messages = compactMessages(messages, offset)
+	}
+
+	s.auditRead(ctx, streamID, string(offset), bytesRead)
+
+	// The digest covers the returned bytes only; an identical re-read
+	// yields the same sum, a rewrite under the same offset does not.
+	if dg := readDigestFrom(ctx); dg != nil {
+		dg.set = true
+	}
+
+	// Report the chunk's exact byte boundaries for resumable downloads.
+	// UUID offsets and ?skip= may have moved the start past what the
+	// client named, so the resolved startOffset is what gets reported.
+	if rng := readRangeFrom(ctx); rng != nil {
+		rng.start = startOffset
+		rng.end = currentOffset
+		rng.total = offsetToInt(tailOffset)
+		rng.set = true
+	}
+
 	return &durablestream.ReadResult{
 		Messages:   messages,
 		NextOffset: nextOffset,
@@ -317,12 +1593,23 @@ func (s *ClaudeStorage) Subscribe(ctx context.Context, streamID string, offset d
 	// Strip leading slash to match watchLoop's streamID format
 	streamID = strings.TrimPrefix(streamID, "/")
 
-	_, err := s.getPath(streamID)
+	if s.noSubscribe != "" {
+		if ok, _ := path.Match(s.noSubscribe, streamID); ok {
+			return nil, ErrSubscribeDisabled
+		}
+	}
+
+	path, err := s.getPathCtx(ctx, streamID)
 	if err != nil {
 		return nil, err
 	}
 
-	ch := make(chan durablestream.Offset, 1)
+	// Notifications are coalescing: sends never block (see notifyChange),
+	// so a full buffer just merges bursts into one wakeup. A buffer of 1
+	// is enough for correctness; a larger one (-subscribe-buffer) lets
+	// batch-processing clients see individual offsets at the cost of a
+	// little memory per subscriber.
+	ch := make(chan durablestream.Offset, subscribeBuffer)
 
 	s.mu.Lock()
 	s.subscribers[streamID] = append(s.subscribers[streamID], ch)
@@ -342,9 +1629,114 @@ func (s *ClaudeStorage) Subscribe(ctx context.Context, streamID string, offset d
 		close(ch)
 	}()
 
-	return ch, nil
+	out := (<-chan durablestream.Offset)(ch)
+
+	// ?types= narrows the subscription to messages of the given types.
+	// watchLoop only knows the tail moved, so the filter reads the new
+	// lines and swallows notifications with no matching message.
+	if types := subscribeTypesFrom(ctx); len(types) > 0 {
+		out = filterSubscription(ctx, out, path, offset, types)
+	}
+
+	// ?coalesce= batches wakeups for this subscriber only, trading
+	// latency for fewer reads; the global debounce is unaffected.
+	if window := coalesceFrom(ctx); window > 0 {
+		out = coalesceSubscription(ctx, out, window)
+	}
+
+	return out, nil
+}
+
+// coalesceSubscription delays each notification to the trailing edge of
+// the window, dropping the intermediate ones, so a rapid write burst
+// surfaces as a single wakeup carrying the latest offset.
+func coalesceSubscription(ctx context.Context, in <-chan durablestream.Offset, window time.Duration) <-chan durablestream.Offset {
+	out := make(chan durablestream.Offset, subscribeBuffer)
+
+	go func() {
+		defer close(out)
+		var (
+			latest durablestream.Offset
+			timerC <-chan time.Time
+		)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case off, ok := <-in:
+				if !ok {
+					return
+				}
+				latest = off
+				if timerC == nil {
+					timerC = time.After(window)
+				}
+			case <-timerC:
+				timerC = nil
+				select {
+				case out <- latest:
+				default: // coalesce, like notifyChange
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// filterSubscription forwards only those notifications where at least
+// one newly appended complete line has a wanted type.
+func filterSubscription(ctx context.Context, in <-chan durablestream.Offset, path string, offset durablestream.Offset, types map[string]bool) <-chan durablestream.Offset {
+	out := make(chan durablestream.Offset, subscribeBuffer)
+	pos := offsetToInt(offset)
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case tail, ok := <-in:
+				if !ok {
+					return
+				}
+				lines, next, err := completeLinesFrom(path, pos)
+				if err != nil {
+					continue
+				}
+				pos = next
+
+				matched := false
+				for _, line := range lines {
+					var entry struct {
+						Type string `json:"type"`
+					}
+					if json.Unmarshal(line, &entry) == nil && types[entry.Type] {
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					continue
+				}
+				select {
+				case out <- tail:
+				default: // coalesce, like notifyChange
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// announceShutdown tells streaming handlers the server is going down.
+// Each one emits a final shutdown event and returns, letting graceful
+// Shutdown complete instead of waiting out open SSE connections.
+func (s *ClaudeStorage) announceShutdown() {
+	s.shutdownOnce.Do(func() { close(s.shutdownCh) })
 }
 
 func (s *ClaudeStorage) Close() error {
+	close(s.done)
 	return s.watcher.Close()
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// toolUseEntry builds an assistant line that is pure tool invocation.
+func toolUseEntry(uuid, tool string) string {
+	return fmt.Sprintf(`{"type":"assistant","uuid":%q,"message":{"role":"assistant","content":[{"type":"tool_use","name":%q,"input":{}}]}}`,
+		uuid, tool)
+}
+
+// toolResultEntry builds a user line carrying only a tool result.
+func toolResultEntry(uuid, result string) string {
+	return fmt.Sprintf(`{"type":"user","uuid":%q,"message":{"role":"user","content":[{"type":"tool_result","content":%q}]}}`,
+		uuid, result)
+}
+
+// timedEntry builds a user line with an explicit timestamp.
+func timedEntry(uuid string, ts time.Time, text string) string {
+	return fmt.Sprintf(`{"type":"user","uuid":%q,"timestamp":%q,"message":{"role":"user","content":%q}}`,
+		uuid, ts.UTC().Format(time.RFC3339), text)
+}
+
+func TestReadSinceFilter(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	cutoff := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	old := timedEntry(testUUID(2), cutoff.Add(-time.Hour), "before")
+	atCutoff := timedEntry(testUUID(3), cutoff, "at")
+	newer := timedEntry(testUUID(4), cutoff.Add(time.Hour), "after")
+	untimed := fmt.Sprintf(`{"type":"user","uuid":%q,"message":{"role":"user","content":"untimed"}}`, testUUID(5))
+	writeStream(t, projectDir, id, old, atCutoff, newer, untimed)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := withReadFilter(context.Background(), &readFilter{since: cutoff})
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("got %d messages, want the 2 at/after the cutoff", len(result.Messages))
+	}
+	if string(result.Messages[0].Data) != atCutoff || string(result.Messages[1].Data) != newer {
+		t.Errorf("wrong messages passed the filter: %s / %s", result.Messages[0].Data, result.Messages[1].Data)
+	}
+
+	// Pagination must still advance past filtered-out lines: the next
+	// offset is the stream tail, not the last included line.
+	if offsetToInt(result.NextOffset) != offsetToInt(result.TailOffset) {
+		t.Errorf("next offset %s stopped short of tail %s", result.NextOffset, result.TailOffset)
+	}
+
+	// includeUntimed lets lines without a timestamp through.
+	ctx = withReadFilter(context.Background(), &readFilter{since: cutoff, includeUntimed: true})
+	result, err = s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(result.Messages) != 3 {
+		t.Fatalf("got %d messages with includeUntimed, want 3", len(result.Messages))
+	}
+}
+
+func TestCompactViewCollapsesToolRuns(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := []string{
+		userEntry(testUUID(2), "run the build"),
+		toolUseEntry(testUUID(3), "Bash"),
+		toolResultEntry(testUUID(4), "build ok"),
+		assistantEntry(testUUID(5), "test-model", "all green"),
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	ctx := withCompactView(context.Background())
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(result.Messages) != 3 {
+		t.Fatalf("got %d messages, want text + summary + text", len(result.Messages))
+	}
+
+	var run compactToolRun
+	if err := json.Unmarshal(result.Messages[1].Data, &run); err != nil {
+		t.Fatal(err)
+	}
+	if run.Type != "tool_summary" || run.Count != 2 {
+		t.Errorf("summary = %+v, want tool_summary covering 2 lines", run)
+	}
+	if len(run.Tools) != 1 || run.Tools[0] != "Bash" {
+		t.Errorf("summary tools = %v, want [Bash]", run.Tools)
+	}
+
+	// The summary keeps the offset of the last collapsed line, so the
+	// following text message resumes contiguously.
+	end := int64(len(lines[0]) + len(lines[1]) + len(lines[2]) + 3)
+	if got := offsetToInt(result.Messages[1].Offset); got != end {
+		t.Errorf("summary offset = %d, want %d", got, end)
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := []string{
+		userEntry(testUUID(2), "one"),
+		userEntry(testUUID(3), "two"),
+		userEntry(testUUID(4), "three"),
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	// ?max_messages= caps the message count and leaves the cursor just
+	// past the last returned line.
+	ctx := withReadLimits(context.Background(), &readLimits{messages: 2})
+	result, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(result.Messages))
+	}
+	want := int64(len(lines[0]) + len(lines[1]) + 2)
+	if got := offsetToInt(result.NextOffset); got != want {
+		t.Errorf("next offset = %d, want %d", got, want)
+	}
+
+	// A byte limit stops the scan once the returned payload reaches it.
+	result, err = s.Read(context.Background(), id, durablestream.ZeroOffset, len(lines[0]))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(result.Messages) != 1 {
+		t.Errorf("got %d messages under a one-line byte limit, want 1", len(result.Messages))
+	}
+}
+
+func TestWritesAreReadOnly(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "hello"))
+	s := newTestStorage(t, claudeDir)
+
+	ctx := context.Background()
+	if _, err := s.Create(ctx, id, durablestream.StreamConfig{}); err != ErrReadOnly {
+		t.Errorf("Create err = %v, want ErrReadOnly", err)
+	}
+	if _, err := s.Append(ctx, id, []byte("{}"), ""); err != ErrReadOnly {
+		t.Errorf("Append err = %v, want ErrReadOnly", err)
+	}
+	if err := s.Delete(ctx, id); err != ErrReadOnly {
+		t.Errorf("Delete err = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestSubscribeSeesWritePromptly(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	first := userEntry(testUUID(2), "opening")
+	path := writeStream(t, projectDir, id, first)
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := s.Subscribe(ctx, id, durablestream.ZeroOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The watcher, not an explicit notifyChange, must carry the write to
+	// the subscriber — and quickly, or long-poll clients sit on stale
+	// tails.
+	start := time.Now()
+	appendLine(t, path, userEntry(testUUID(3), "fresh line"))
+
+	select {
+	case off, ok := <-ch:
+		if !ok {
+			t.Fatal("subscription closed early")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("notification took %v, want well under a second", elapsed)
+		}
+		// The delivered offset reflects the grown tail.
+		if offsetToInt(off) <= int64(len(first)+1) {
+			t.Errorf("notified offset %s does not cover the new line", off)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("write never reached the subscriber")
+	}
+}
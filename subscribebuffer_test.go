@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestSubscribeBufferDepth(t *testing.T) {
+	old := subscribeBuffer
+	subscribeBuffer = 3
+	t.Cleanup(func() { subscribeBuffer = old })
+
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "buffered"))
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := s.Subscribe(ctx, id, durablestream.ZeroOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Four notifications against a depth of three: the first three are
+	// buffered for a batch-processing client, the fourth is dropped
+	// rather than blocking the notifier.
+	for i := 0; i < 4; i++ {
+		s.notifyChange(path)
+	}
+	if got := len(ch); got != 3 {
+		t.Errorf("buffered notifications = %d, want 3", got)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ch:
+		default:
+			t.Fatalf("notification %d missing from the buffer", i)
+		}
+	}
+	select {
+	case <-ch:
+		t.Error("dropped notification was delivered anyway")
+	default:
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+// appendLine appends one JSONL line to an existing stream file.
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubscribeTypeFilter(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	first := userEntry(testUUID(2), "opening")
+	path := writeStream(t, projectDir, id, first)
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = withSubscribeTypes(ctx, map[string]bool{"assistant": true})
+
+	tail := durablestream.Offset(offsetFromInt(int64(len(first) + 1)))
+	ch, err := s.Subscribe(ctx, id, tail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new user line moves the tail but matches nothing: the
+	// notification is swallowed.
+	appendLine(t, path, userEntry(testUUID(3), "not for you"))
+	s.notifyChange(path)
+	select {
+	case off := <-ch:
+		t.Fatalf("woken at %s for a filtered-out type", off)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// An assistant line gets through.
+	appendLine(t, path, assistantEntry(testUUID(4), "test-model", "for you"))
+	s.notifyChange(path)
+	select {
+	case _, ok := <-ch:
+		if !ok {
+			t.Fatal("subscription closed early")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no wakeup for a matching type")
+	}
+}
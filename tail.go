@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultTailLines = 10
+
+// maxFollowBacklog bounds how many bytes a follow stream will try to
+// push to a slow client before giving up and resyncing. Without this, a
+// fast writer and a slow reader would make the server replay an
+// ever-growing backlog.
+const maxFollowBacklog = 1 << 20
+
+// resyncEvent is the control line emitted when a follower has fallen too
+// far behind. The client should drop its state and re-read from Offset.
+type resyncEvent struct {
+	Type   string `json:"type"`
+	Offset string `json:"offset,omitempty"`
+}
+
+// handleTail serves the last N lines of a conversation as NDJSON and,
+// with follow=1, holds the connection open streaming new lines as they
+// arrive — the HTTP equivalent of `tail -f`.
+//
+// GET /_tail/{streamID}?n=N&follow=1
+func (s *ClaudeStorage) handleTail(w http.ResponseWriter, r *http.Request) {
+	streamID := strings.TrimPrefix(r.URL.Path, "/_tail/")
+
+	n := defaultTailLines
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		n, err = strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			apiError(w, http.StatusBadRequest, codeBadRequest, "invalid n")
+			return
+		}
+	}
+	follow := r.URL.Query().Get("follow") == "1"
+
+	path, err := s.getPath(streamID)
+	if err != nil {
+		apiError(w, http.StatusNotFound, codeStreamNotFound, "stream not found")
+		return
+	}
+
+	lines, endOffset, err := lastLines(path, n)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, codeInternal, "read stream")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher, _ := w.(http.Flusher)
+
+	pretty := r.URL.Query().Get("pretty") == "1"
+	for _, line := range lines {
+		if pretty {
+			line = prettyJSON(line, "")
+		}
+		w.Write(line)
+		io.WriteString(w, "\n")
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	if !follow {
+		return
+	}
+
+	ctx := r.Context()
+	ch, err := s.Subscribe(ctx, streamID, offsetFromInt(endOffset))
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.shutdownCh:
+			ev, _ := json.Marshal(resyncEvent{Type: "_shutdown"})
+			w.Write(ev)
+			io.WriteString(w, "\n")
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+			// The file may have been truncated and rewritten out from
+			// under us; reset and re-stream from the start.
+			if info, err := os.Stat(path); err == nil {
+				if info.Size() < endOffset {
+					endOffset = 0
+				}
+				// A slow client that has fallen too far behind gets a
+				// resync marker instead of the whole backlog.
+				if info.Size()-endOffset > maxFollowBacklog {
+					endOffset = info.Size()
+					ev, _ := json.Marshal(resyncEvent{Type: "_resync", Offset: string(offsetFromInt(endOffset))})
+					w.Write(ev)
+					io.WriteString(w, "\n")
+					if flusher != nil {
+						flusher.Flush()
+					}
+					continue
+				}
+			}
+			endOffset = streamFrom(w, path, endOffset)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// lastLines returns the final n valid JSONL lines of a file and the byte
+// offset of its end.
+func lastLines(path string, n int) ([][]byte, int64, error) {
+	f, err := openStream(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var (
+		lines  [][]byte
+		offset int64
+	)
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+	scanner.Split(scanLinesKeepCR)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		offset += int64(len(line) + 1)
+		data := bytes.TrimSuffix(line, []byte{'\r'})
+		if !json.Valid(data) {
+			continue
+		}
+		lines = append(lines, append([]byte(nil), data...))
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	return lines, offset, nil
+}
+
+// streamFrom writes all complete lines after offset to w and returns the
+// new end offset.
+func streamFrom(w io.Writer, path string, offset int64) int64 {
+	f, err := openStream(path)
+	if err != nil {
+		return offset
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(io.Discard, f, offset); err != nil {
+		return offset
+	}
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+	scanner.Split(scanLinesKeepCR)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		offset += int64(len(line) + 1)
+		data := bytes.TrimSuffix(line, []byte{'\r'})
+		if !json.Valid(data) {
+			continue
+		}
+		w.Write(data)
+		io.WriteString(w, "\n")
+	}
+	return offset
+}
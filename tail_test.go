@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLastLines(t *testing.T) {
+	_, projectDir := newTestClaudeDir(t)
+	lines := []string{
+		userEntry(testUUID(1), "one"),
+		"not json",
+		userEntry(testUUID(2), "two"),
+		userEntry(testUUID(3), "three"),
+	}
+	path := writeStream(t, projectDir, "tail", lines...)
+
+	got, end, err := lastLines(path, 2)
+	if err != nil {
+		t.Fatalf("lastLines: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d lines, want 2", len(got))
+	}
+	if string(got[0]) != lines[2] || string(got[1]) != lines[3] {
+		t.Errorf("wrong tail lines: %s / %s", got[0], got[1])
+	}
+
+	var total int64
+	for _, l := range lines {
+		total += int64(len(l) + 1)
+	}
+	if end != total {
+		t.Errorf("end offset = %d, want %d", end, total)
+	}
+}
+
+func TestTailEndpoint(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := []string{
+		userEntry(testUUID(2), "old"),
+		userEntry(testUUID(3), "newer"),
+		userEntry(testUUID(4), "newest"),
+	}
+	writeStream(t, projectDir, id, lines...)
+	s := newTestStorage(t, claudeDir)
+
+	r := httptest.NewRequest("GET", "/_tail/"+id+"?n=2", nil)
+	w := httptest.NewRecorder()
+	s.handleTail(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	got := strings.Split(strings.TrimRight(w.Body.String(), "\n"), "\n")
+	if len(got) != 2 {
+		t.Fatalf("got %d lines, want 2", len(got))
+	}
+	if got[0] != lines[1] || got[1] != lines[2] {
+		t.Errorf("wrong tail: %v", got)
+	}
+
+	r = httptest.NewRequest("GET", "/_tail/"+id+"?n=0", nil)
+	w = httptest.NewRecorder()
+	s.handleTail(w, r)
+	if w.Code != 400 {
+		t.Errorf("n=0: status = %d, want 400", w.Code)
+	}
+}
+
+// lockedRecorder lets a test read the response body while the handler
+// goroutine is still streaming into it.
+type lockedRecorder struct {
+	mu  sync.Mutex
+	rec *httptest.ResponseRecorder
+}
+
+func (l *lockedRecorder) Header() http.Header { return l.rec.Header() }
+
+func (l *lockedRecorder) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rec.Write(p)
+}
+
+func (l *lockedRecorder) WriteHeader(code int) { l.rec.WriteHeader(code) }
+
+func (l *lockedRecorder) Flush() {}
+
+func (l *lockedRecorder) body() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rec.Body.String()
+}
+
+func TestFollowResyncsSlowReader(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	path := writeStream(t, projectDir, id, userEntry(testUUID(2), "start"))
+	s := newTestStorage(t, claudeDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/_tail/"+id+"?n=1&follow=1", nil).WithContext(ctx)
+	w := &lockedRecorder{rec: httptest.NewRecorder()}
+	done := make(chan struct{})
+	go func() {
+		s.handleTail(w, r)
+		close(done)
+	}()
+
+	// Wait for the follower to subscribe before producing the backlog.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.RLock()
+		n := len(s.subscribers[id])
+		s.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("follower never subscribed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Land more than maxFollowBacklog in a single write, so the first
+	// notification already finds the follower hopelessly behind.
+	huge := `{"type":"user","message":{"role":"user","content":"backlog-payload` +
+		strings.Repeat("x", maxFollowBacklog) + `"}}` + "\n"
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(huge); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	s.notifyChange(path)
+
+	// The resync marker arrives instead of the backlog.
+	deadline = time.Now().Add(2 * time.Second)
+	for !strings.Contains(w.body(), `"_resync"`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("no resync event; body:\n%.200s", w.body())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	body := w.body()
+	if strings.Contains(body, "backlog-payload") {
+		t.Error("backlog was replayed instead of skipped")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := string(offsetFromInt(info.Size()))
+	if !strings.Contains(body, want) {
+		t.Errorf("resync offset missing; want %s in:\n%.300s", want, body)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// globalReadRate throttles all reads combined to this many bytes per
+// second. Set by -read-rate; 0 disables.
+var globalReadRate int
+
+// streamReadRate throttles each stream's reads to this many bytes per
+// second. Set by -stream-read-rate; 0 disables.
+var streamReadRate int
+
+// tokenBucket is a simple bytes-per-second rate limiter. A bucket holds
+// up to one second of budget; a caller needing more than the bucket can
+// hold is allowed through with the balance going negative, so oversized
+// lines slow subsequent reads instead of stalling forever.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int) *tokenBucket {
+	return &tokenBucket{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// wait blocks until n bytes of budget are available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context, n int) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+		b.last = now
+		if b.tokens >= float64(n) || b.tokens >= b.rate {
+			b.tokens -= float64(n)
+			b.mu.Unlock()
+			return nil
+		}
+		need := time.Duration((float64(n) - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		t := time.NewTimer(need)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// throttleRead paces Read's scan loop against the global and per-stream
+// budgets. Disabled throttles cost nothing; a throttled read slows down
+// rather than failing, and still honors context cancellation.
+func (s *ClaudeStorage) throttleRead(ctx context.Context, streamID string, n int) error {
+	if globalReadRate > 0 {
+		s.mu.Lock()
+		if s.globalBucket == nil {
+			s.globalBucket = newTokenBucket(globalReadRate)
+		}
+		bucket := s.globalBucket
+		s.mu.Unlock()
+		if err := bucket.wait(ctx, n); err != nil {
+			return err
+		}
+	}
+	if streamReadRate > 0 {
+		s.mu.Lock()
+		if s.readBuckets == nil {
+			s.readBuckets = make(map[string]*tokenBucket)
+		}
+		bucket, ok := s.readBuckets[streamID]
+		if !ok {
+			bucket = newTokenBucket(streamReadRate)
+			s.readBuckets[streamID] = bucket
+		}
+		s.mu.Unlock()
+		if err := bucket.wait(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
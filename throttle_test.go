@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestReadThrottlePacesScan(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = userEntry(testUUID(100+i), "payload to meter out slowly")
+	}
+	path := writeStream(t, projectDir, id, lines...)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One second of budget is half the file: the bucket starts full, so
+	// the second half has to wait roughly half a second.
+	old := streamReadRate
+	streamReadRate = int(info.Size() / 2)
+	t.Cleanup(func() { streamReadRate = old })
+
+	s := newTestStorage(t, claudeDir)
+	start := time.Now()
+	result, err := s.Read(context.Background(), id, durablestream.ZeroOffset, maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if len(result.Messages) != len(lines) {
+		t.Fatalf("throttled read returned %d of %d messages", len(result.Messages), len(lines))
+	}
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("read finished in %v; expected pacing near half a second", elapsed)
+	}
+
+	// A throttled read still honors cancellation instead of sleeping out
+	// its budget.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := s.Read(ctx, id, durablestream.ZeroOffset, maxReadLimitBytes); err == nil {
+		t.Error("throttled read ignored the expired context")
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("canceled throttled read took %v to return", waited)
+	}
+}
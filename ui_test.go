@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUIFilesystemExternalDir(t *testing.T) {
+	dir := t.TempDir()
+	page := "<html>custom ui build</html>"
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := uiFilesystem(dir)
+	if err != nil {
+		t.Fatalf("uiFilesystem: %v", err)
+	}
+	h := spaHandler(http.FileServer(http.FS(fsys)))
+
+	// /index.html itself 301s to ./ under http.FileServer, so test the
+	// root and an extensionless SPA route.
+	for _, path := range []string{"/", "/some/spa/route"} {
+		r := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != 200 {
+			t.Errorf("GET %s: status = %d", path, w.Code)
+			continue
+		}
+		if !strings.Contains(w.Body.String(), "custom ui build") {
+			t.Errorf("GET %s: body = %q, want the external index.html", path, w.Body.String())
+		}
+	}
+}
+
+func TestUIFilesystemMissingIndex(t *testing.T) {
+	if _, err := uiFilesystem(t.TempDir()); err == nil {
+		t.Error("uiFilesystem accepted a directory without index.html")
+	}
+}
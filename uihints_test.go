@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAnnotateEntryDerivesUIHints(t *testing.T) {
+	decode := func(t *testing.T, raw []byte) (json.RawMessage, entryAnnotations) {
+		t.Helper()
+		var wrapped struct {
+			Entry       json.RawMessage  `json:"entry"`
+			Annotations entryAnnotations `json:"annotations"`
+		}
+		if err := json.Unmarshal(raw, &wrapped); err != nil {
+			t.Fatalf("annotated line is not the wrapper shape: %v\n%s", err, raw)
+		}
+		return wrapped.Entry, wrapped.Annotations
+	}
+
+	// A user line with fenced code: role, hasCode and a token estimate.
+	userLine := `{"type":"user","message":{"role":"user","content":"run this: ` + "```go fmt.Println() ```" + `"}}`
+	entry, ann := decode(t, annotateEntry([]byte(userLine)))
+	if ann.Role != "user" || !ann.HasCode || ann.ApproxTokens == 0 {
+		t.Errorf("user annotations = %+v", ann)
+	}
+	if string(entry) != userLine {
+		t.Errorf("raw entry was altered:\n%s", entry)
+	}
+
+	// An assistant line with content blocks.
+	asst := assistantEntry(testUUID(1), "test-model", "plain prose reply")
+	_, ann = decode(t, annotateEntry([]byte(asst)))
+	if ann.Role != "assistant" || ann.HasCode || ann.IsError {
+		t.Errorf("assistant annotations = %+v", ann)
+	}
+
+	// A failed tool_result sets the error flag.
+	toolLine := `{"type":"user","message":{"role":"user","content":[{"type":"tool_result","is_error":true,"content":"boom"}]}}`
+	_, ann = decode(t, annotateEntry([]byte(toolLine)))
+	if !ann.IsError {
+		t.Errorf("tool failure annotations = %+v, want isError", ann)
+	}
+
+	// Unparseable lines pass through untouched.
+	if got := annotateEntry([]byte("not json")); string(got) != "not json" {
+		t.Errorf("garbage line rewritten to %q", got)
+	}
+}
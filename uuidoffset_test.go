@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ahimsalabs/durable-streams-go/durablestream"
+)
+
+func TestReadResumesFromUUIDOffset(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	first := userEntry(testUUID(2), "first")
+	second := assistantEntry(testUUID(3), "test-model", "second")
+	third := userEntry(testUUID(4), "third")
+	writeStream(t, projectDir, id, first, second, third)
+	s := newTestStorage(t, claudeDir)
+
+	// Naming the second message's UUID resumes just after it.
+	ctx := context.Background()
+	result, err := s.Read(ctx, id, durablestream.Offset(testUUID(3)), maxReadLimitBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0].Data) != third {
+		t.Fatalf("resumed read = %+v, want only the third line", result.Messages)
+	}
+
+	// NextOffset comes back as a normal byte offset at the tail.
+	total := int64(len(first) + len(second) + len(third) + 3)
+	if got := offsetToInt(result.NextOffset); got != total {
+		t.Errorf("next offset = %d, want byte offset %d", got, total)
+	}
+}
+
+func TestReadUnknownUUIDOffset(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(2), "only line"))
+	s := newTestStorage(t, claudeDir)
+
+	_, err := s.Read(context.Background(), id, durablestream.Offset(testUUID(9)), maxReadLimitBytes)
+	if !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("err = %v, want ErrMessageNotFound", err)
+	}
+}
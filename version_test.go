@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIVersionPrefixAndHeader(t *testing.T) {
+	claudeDir, projectDir := newTestClaudeDir(t)
+	writeStream(t, projectDir, testUUID(1), userEntry(testUUID(2), "hi"))
+	s := newTestStorage(t, claudeDir)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_healthz", s.handleHealthz)
+	handler := apiVersionMiddleware(mux)
+
+	// The same endpoint resolves on both the canonical prefixed path and
+	// the legacy unprefixed one, and both carry the version header.
+	for _, path := range []string{"/_healthz", "/v1/_healthz"} {
+		r := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != 200 {
+			t.Errorf("%s: status = %d, body %s", path, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("X-Claude2Stream-Version"); got != apiVersion {
+			t.Errorf("%s: version header = %q, want %q", path, got, apiVersion)
+		}
+		if !strings.Contains(w.Body.String(), "ready") {
+			t.Errorf("%s: unexpected body %s", path, w.Body.String())
+		}
+	}
+
+	// An unknown version prefix is not stripped.
+	r := httptest.NewRequest("GET", "/v9/_healthz", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != 404 {
+		t.Errorf("/v9 prefix: status = %d, want 404", w.Code)
+	}
+}
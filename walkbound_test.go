@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWalkFallbackHonorsContext(t *testing.T) {
+	claudeDir, _ := newTestClaudeDir(t)
+
+	// Filler files that the deep walk must visit first (lexical order),
+	// so it passes a context checkpoint before reaching the target.
+	filler := filepath.Join(claudeDir, "projects", "-aaa-filler")
+	if err := os.MkdirAll(filler, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3*ctxCheckEvery; i++ {
+		name := filepath.Join(filler, fmt.Sprintf("filler-%04d.txt", i))
+		if err := os.WriteFile(name, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The target is nested too deep for the one-level glob fallback;
+	// only the full walk can find it.
+	deep := filepath.Join(claudeDir, "projects", "-zzz-deep", "sub")
+	if err := os.MkdirAll(deep, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	id := testUUID(1)
+	writeStream(t, deep, id, userEntry(testUUID(2), "buried"))
+
+	s := newTestStorage(t, claudeDir)
+
+	dropIndexEntry := func() {
+		s.mu.Lock()
+		delete(s.fileIndex, id)
+		s.mu.Unlock()
+	}
+
+	// Sanity: with a live context the bounded walk finds the file.
+	dropIndexEntry()
+	if _, err := s.getPathCtx(context.Background(), id); err != nil {
+		t.Fatalf("walk with live context: %v", err)
+	}
+
+	// With a canceled context the walk aborts at a checkpoint and the
+	// stream resolves as not found rather than scanning the whole tree.
+	dropIndexEntry()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.getPathCtx(ctx, id)
+	if !errors.Is(err, ErrStreamNotFound) {
+		t.Fatalf("walk with canceled context: err = %v, want ErrStreamNotFound", err)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// waitForIndex polls the file index directly — resolution fallbacks
+// would mask a missed watch event — until the ID appears or the
+// deadline hits.
+func waitForIndex(t *testing.T, s *ClaudeStorage, id string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.RLock()
+		_, ok := s.fileIndex[id]
+		s.mu.RUnlock()
+		if ok {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("stream %s never appeared in the index", id)
+}
+
+func TestWatchDetectsNestedNewDirectories(t *testing.T) {
+	claudeDir, _ := newTestClaudeDir(t)
+	s := newTestStorage(t, claudeDir)
+
+	// A project directory created after startup, with a conversation
+	// nested one level deeper, must still be picked up: the create
+	// events cascade watches down the new subtree.
+	newProject := filepath.Join(claudeDir, "projects", "-new-project")
+	if err := os.MkdirAll(newProject, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	id := testUUID(1)
+	// Give the new watch a moment to land before writing into the dir.
+	time.Sleep(100 * time.Millisecond)
+	writeStream(t, newProject, id, userEntry(testUUID(10), "born late"))
+	waitForIndex(t, s, id)
+
+	nested := filepath.Join(newProject, "subdir")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	nestedID := testUUID(2)
+	time.Sleep(100 * time.Millisecond)
+	writeStream(t, nested, nestedID, userEntry(testUUID(11), "deeper still"))
+	waitForIndex(t, s, nestedID)
+}
+
+func TestWatchLimitFallsBackToPolling(t *testing.T) {
+	oldAdd, oldInterval := watcherAdd, pollInterval
+	pollInterval = 50 * time.Millisecond
+	// Simulate the kernel watch limit for the project directory only;
+	// everything else keeps real watches.
+	watcherAdd = func(w *fsnotify.Watcher, dir string) error {
+		if strings.Contains(dir, "-test-project") {
+			return syscall.ENOSPC
+		}
+		return w.Add(dir)
+	}
+	t.Cleanup(func() { watcherAdd, pollInterval = oldAdd, oldInterval })
+
+	claudeDir, projectDir := newTestClaudeDir(t)
+	s := newTestStorage(t, claudeDir)
+
+	s.mu.RLock()
+	_, polled := s.polledDirs[projectDir]
+	s.mu.RUnlock()
+	if !polled {
+		t.Fatal("project dir did not degrade to polling after a failed Add")
+	}
+
+	// No watch covers the directory, so only the poll loop can notice
+	// this file appearing.
+	id := testUUID(1)
+	writeStream(t, projectDir, id, userEntry(testUUID(10), "seen by polling"))
+	waitForIndex(t, s, id)
+}
+
+func TestWatchSweepsFilesRacingTheWatch(t *testing.T) {
+	claudeDir, _ := newTestClaudeDir(t)
+	s := newTestStorage(t, claudeDir)
+
+	// Directory and file created back to back: the file write can beat
+	// the watch registration, so watchNewDir's sweep must find it.
+	newProject := filepath.Join(claudeDir, "projects", "-raced-project")
+	if err := os.MkdirAll(newProject, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	id := testUUID(3)
+	writeStream(t, newProject, id, userEntry(testUUID(12), "raced in"))
+	waitForIndex(t, s, id)
+}